@@ -0,0 +1,239 @@
+package socialite
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// AuthOption option of AuthorizeURL
+type AuthOption func(*AuthOptions)
+
+// AuthOptions options of the authorize url, each provider maps them to its native params
+// and returns an error for the ones it does not support
+type AuthOptions struct {
+	State  string
+	Scopes []string
+	// Display e.g. mobile (weibo, qq), popup (facebook)
+	Display string
+	// RedirectURL overrides the RedirectURL of the provider for this request
+	RedirectURL string
+	// LoginHint e.g. email (microsoft, kakao), screen_name (twitter)
+	LoginHint string
+	// Prompt e.g. login, consent
+	Prompt string
+	// Extra provider specific params, added as is, reserved params (redirect_uri, state, ...) are rejected
+	Extra map[string]string
+	// GrantedScopes scopes already granted to the linked account, only the missing ones are requested
+	GrantedScopes []Scope
+}
+
+// WithState state, echoed back to the callback
+func WithState(state string) AuthOption {
+	return func(o *AuthOptions) {
+		o.State = state
+	}
+}
+
+// WithScopes scopes, joined with the separator of the provider
+func WithScopes(scopes ...string) AuthOption {
+	return func(o *AuthOptions) {
+		o.Scopes = append(o.Scopes, scopes...)
+	}
+}
+
+// WithDisplay display of the authorize page
+func WithDisplay(display string) AuthOption {
+	return func(o *AuthOptions) {
+		o.Display = display
+	}
+}
+
+// WithRedirectURL per-request redirect url
+func WithRedirectURL(redirectURL string) AuthOption {
+	return func(o *AuthOptions) {
+		o.RedirectURL = redirectURL
+	}
+}
+
+// WithLoginHint pre-fill the account of the login page
+func WithLoginHint(loginHint string) AuthOption {
+	return func(o *AuthOptions) {
+		o.LoginHint = loginHint
+	}
+}
+
+// WithPrompt prompt of the login page
+func WithPrompt(prompt string) AuthOption {
+	return func(o *AuthOptions) {
+		o.Prompt = prompt
+	}
+}
+
+// WithExtra provider specific param, it must not be one of the params set by the provider
+func WithExtra(key, val string) AuthOption {
+	return func(o *AuthOptions) {
+		if o.Extra == nil {
+			o.Extra = make(map[string]string)
+		}
+		o.Extra[key] = val
+	}
+}
+
+// WithGrantedScopes step-up consent of a linked account, the granted scopes are dropped from the
+// requested ones, providers without incremental consent (qq, weibo, line) request them again
+func WithGrantedScopes(scopes ...Scope) AuthOption {
+	return func(o *AuthOptions) {
+		o.GrantedScopes = append(o.GrantedScopes, scopes...)
+	}
+}
+
+// ErrScopesGranted all the requested scopes are granted already
+var ErrScopesGranted = errors.New("all the scopes are granted")
+
+// names of the options
+const (
+	authState       = "state"
+	authScopes      = "scopes"
+	authDisplay     = "display"
+	authRedirectURL = "redirect_url"
+	authLoginHint   = "login_hint"
+	authPrompt      = "prompt"
+	authGranted     = "granted_scopes"
+)
+
+// reservedExtra params which must not be set by WithExtra, they would bypass the checks of the
+// redirect url, the state and the scopes
+var reservedExtra = []string{"client_id", "appid", "redirect_uri", "return_to", "response_type", "state", "scope",
+	"bot_id", "origin", "oauth_token"}
+
+// newAuthOptions apply the options
+func newAuthOptions(opts []AuthOption) *AuthOptions {
+	ret := new(AuthOptions)
+	for _, opt := range opts {
+		opt(ret)
+	}
+	return ret
+}
+
+// supports error for the options which are set but not in the supported ones
+func (o *AuthOptions) supports(names ...string) error {
+
+	set := map[string]bool{
+		authState:       o.State != "",
+		authScopes:      len(o.Scopes) > 0,
+		authDisplay:     o.Display != "",
+		authRedirectURL: o.RedirectURL != "",
+		authLoginHint:   o.LoginHint != "",
+		authPrompt:      o.Prompt != "",
+		authGranted:     o.incremental(),
+	}
+	for _, name := range names {
+		delete(set, name)
+	}
+
+	var unsupported []string
+	for name, ok := range set {
+		if ok {
+			unsupported = append(unsupported, name)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return fmt.Errorf("%s is not supported", strings.Join(unsupported, ", "))
+	}
+	return o.checkExtra()
+}
+
+// checkExtra error for the reserved extra params
+func (o *AuthOptions) checkExtra() error {
+	for _, key := range reservedExtra {
+		if _, ok := o.Extra[key]; ok {
+			return fmt.Errorf("extra param %q is reserved", key)
+		}
+	}
+	return nil
+}
+
+// scope scopes joined with the separator
+func (o *AuthOptions) scope(sep string) string {
+	return strings.Join(o.Scopes, sep)
+}
+
+// incremental whether it is a step-up consent of a linked account
+func (o *AuthOptions) incremental() bool {
+	return len(o.GrantedScopes) > 0
+}
+
+// joinScopes validate the scopes against the catalog and join them with its separator,
+// for a step-up consent the granted scopes are dropped, or requested again when the provider has no incremental consent
+func (o *AuthOptions) joinScopes(catalog *ScopeCatalog) (string, error) {
+	scopes := ToScopes(o.Scopes...)
+	if err := catalog.Validate(scopes...); err != nil {
+		return "", err
+	}
+	if o.incremental() {
+		if catalog.Covers(o.GrantedScopes, scopes...) {
+			return "", ErrScopesGranted
+		}
+		if catalog.Incremental {
+			scopes = subtractScopes(scopes, o.GrantedScopes)
+		} else {
+			scopes = MergeScopes(o.GrantedScopes, scopes...)
+		}
+	}
+	return catalog.Join(scopes...), nil
+}
+
+// redirectURL the override or the default one
+func (o *AuthOptions) redirectURL(redirectURL string) string {
+	if o.RedirectURL != "" {
+		return o.RedirectURL
+	}
+	return redirectURL
+}
+
+// apply set the non-empty params and the extra ones, the extra ones must not replace the others
+func (o *AuthOptions) apply(params map[string]string, optional map[string]string) (map[string]string, error) {
+	for k, v := range optional {
+		if v != "" {
+			params[k] = v
+		}
+	}
+	for k, v := range o.Extra {
+		if _, ok := params[k]; ok {
+			return nil, fmt.Errorf("extra param %q is reserved", k)
+		}
+		params[k] = v
+	}
+	return params, nil
+}
+
+// withExtra add the extra params to the query of the url, the fragment is kept, the extra ones
+// must not duplicate the params of the url
+func (o *AuthOptions) withExtra(rawURL string) (string, error) {
+
+	if len(o.Extra) == 0 {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	query, extra := u.Query(), url.Values{}
+	for k, v := range o.Extra {
+		if _, ok := query[k]; ok {
+			return "", fmt.Errorf("extra param %q is reserved", k)
+		}
+		extra.Set(k, v)
+	}
+	if u.RawQuery != "" {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += extra.Encode()
+	return u.String(), nil
+}
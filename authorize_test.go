@@ -0,0 +1,189 @@
+package socialite
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// all providers support the unified authorize url
+var (
+	_ ISocialite = &Default{}
+	_ ISocialite = &Qq{}
+	_ ISocialite = &Wechat{}
+	_ ISocialite = &WechatOfficialAccount{}
+	_ ISocialite = &WechatApp{}
+	_ ISocialite = &Weibo{}
+	_ ISocialite = &Microsoft{}
+	_ ISocialite = &Facebook{}
+	_ ISocialite = &Twitter{}
+	_ ISocialite = &Line{}
+	_ ISocialite = &Kakao{}
+	_ ISocialite = &Naver{}
+	_ ISocialite = &Telegram{}
+)
+
+// TestAuthOptions
+func TestAuthOptions(t *testing.T) {
+
+	ast := assert.New(t)
+
+	o := newAuthOptions([]AuthOption{WithState("STATE"), WithScopes("a"), WithScopes("b", "c"), WithExtra("k", "v")})
+	ast.Equal("STATE", o.State)
+	ast.Equal("a,b,c", o.scope(","))
+	ast.Equal(map[string]string{"k": "v"}, o.Extra)
+	ast.Nil(o.supports(authState, authScopes))
+
+	o = newAuthOptions([]AuthOption{WithState("STATE"), WithPrompt("login"), WithLoginHint("user")})
+	ast.EqualError(o.supports(authState), "login_hint, prompt is not supported")
+
+	ret, err := o.withExtra("https://domain.com/authorize?a=1#fragment")
+	ast.Nil(err)
+	ast.Equal("https://domain.com/authorize?a=1#fragment", ret)
+
+	WithExtra("b", "2 3")(o)
+	ret, err = o.withExtra("https://domain.com/authorize?a=1#fragment")
+	ast.Nil(err)
+	ast.Equal("https://domain.com/authorize?a=1&b=2+3#fragment", ret)
+}
+
+// TestAuthorizeURL
+func TestAuthorizeURL(t *testing.T) {
+
+	ast := assert.New(t)
+
+	wb := *wbObj
+	wb.RedirectAllowlist = RedirectAllowlist{"https://*.domain.com/wb/callback"}
+
+	cases := []struct {
+		name string
+		s    ISocialite
+		opts []AuthOption
+		url  string
+	}{
+		{"qq", qqObj, []AuthOption{WithState("STATE"), WithScopes("get_user_info", "list_album"), WithDisplay("mobile")},
+			"https://graph.qq.com/oauth2.0/authorize?client_id=test_app_id&display=mobile&redirect_uri=http%3A%2F%2Flocalhost%2Fredirect_uri&response_type=code&scope=get_user_info%2Clist_album&state=STATE"},
+		{"wechat", wxObj, []AuthOption{WithState("STATE"), WithExtra("lang", "en")},
+			"https://open.weixin.qq.com/connect/qrconnect?appid=APPID&redirect_uri=REDIRECT_URI&response_type=code&scope=snsapi_login&state=STATE&lang=en"},
+		{"wechat official account", wxOaObj, []AuthOption{WithState("STATE"), WithScopes(WxScopeBase), WithPrompt("consent")},
+			"https://open.weixin.qq.com/connect/oauth2/authorize?appid=APPID&redirect_uri=https%3A%2F%2Fdomain.com%2Fwx%2Fcallback&response_type=code&scope=snsapi_base&state=STATE&forcePopup=true#wechat_redirect"},
+		{"weibo", &wb, []AuthOption{WithState("STATE"), WithDisplay("mobile"), WithPrompt("login"), WithRedirectURL("https://a.domain.com/wb/callback")},
+			"https://api.weibo.com/oauth2/authorize?client_id=CLIENT_ID&display=mobile&forcelogin=true&redirect_uri=https%3A%2F%2Fa.domain.com%2Fwb%2Fcallback&state=STATE"},
+		{"microsoft", msObj, []AuthOption{WithState("STATE"), WithScopes("openid", "User.Read"), WithLoginHint("user@domain.com"), WithPrompt("select_account")},
+			"https://login.microsoftonline.com/common/oauth2/v2.0/authorize?client_id=CLIENT_ID&login_hint=user%40domain.com&prompt=select_account&redirect_uri=REDIRECT_URI&response_mode=query&response_type=code&scope=openid+User.Read&state=STATE"},
+		{"facebook", fbObj, []AuthOption{WithState("STATE"), WithScopes("email", "public_profile"), WithDisplay("popup")},
+			"https://www.facebook.com/v8.0/dialog/oauth?client_id=APP_ID&display=popup&redirect_uri=REDIRECT_URI&response_type=code&scope=email%2Cpublic_profile&state=STATE"},
+		{"line", lnObj, []AuthOption{WithState("STATE"), WithPrompt("consent"), WithExtra("nonce", "NONCE")},
+			"https://access.line.me/oauth2/v2.1/authorize?bot_prompt=normal&client_id=1234567890&nonce=NONCE&prompt=consent&redirect_uri=REDIRECT_URI&response_type=code&scope=profile+openid&state=STATE"},
+		{"kakao", kkObj, []AuthOption{WithState("STATE"), WithScopes("profile", "account_email"), WithPrompt("login")},
+			"https://kauth.kakao.com/oauth/authorize?client_id=REST_API_KEY&prompt=login&redirect_uri=REDIRECT_URI&response_type=code&scope=profile%2Caccount_email&state=STATE"},
+		{"naver", nvObj, []AuthOption{WithState("STATE")},
+			"https://nid.naver.com/oauth2.0/authorize?client_id=CLIENT_ID&redirect_uri=REDIRECT_URI&response_type=code&state=STATE"},
+		{"telegram", tgObj, []AuthOption{WithScopes("write")},
+			"https://oauth.telegram.org/auth?bot_id=123456&origin=https%3A%2F%2Fdomain.com&request_access=write&return_to=https%3A%2F%2Fdomain.com%2Ftg%2Fcallback"},
+	}
+
+	for _, c := range cases {
+		ret, err := c.s.AuthorizeURL(c.opts...)
+		if err != nil {
+			ast.Fail(err.Error(), c.name)
+			continue
+		}
+		ast.Equal(c.url, ret, c.name)
+	}
+
+	// fail
+	fails := []struct {
+		name string
+		s    ISocialite
+		opts []AuthOption
+	}{
+		{"default", &Default{}, nil},
+		{"qq without state", qqObj, nil},
+		{"qq login hint", qqObj, []AuthOption{WithState("STATE"), WithLoginHint("user")}},
+		{"qq display", qqObj, []AuthOption{WithState("STATE"), WithDisplay("wap")}},
+		{"qq invalid scope", qqObj, []AuthOption{WithState("STATE"), WithScopes("get_info,add_share")}},
+		{"line unknown scope", lnObj, []AuthOption{WithState("STATE"), WithScopes("friends")}},
+		{"wechat display", wxObj, []AuthOption{WithDisplay("mobile")}},
+		{"wechat app", wxAppObj, []AuthOption{WithState("STATE")}},
+		{"weibo prompt", wbObj, []AuthOption{WithPrompt("consent")}},
+		{"weibo redirect url without allowlist", wbObj, []AuthOption{WithRedirectURL("https://a.domain.com/wb/callback")}},
+		{"weibo redirect url not allowed", &wb, []AuthOption{WithRedirectURL("https://evil.com/wb/callback")}},
+		{"facebook redirect url not allowed", fbObj, []AuthOption{WithRedirectURL("https://evil.com/fb/callback")}},
+		{"naver without state", nvObj, nil},
+		{"telegram state", tgObj, []AuthOption{WithState("STATE")}},
+		{"twitter state", twObj, []AuthOption{WithState("STATE")}},
+	}
+
+	for _, c := range fails {
+		_, err := c.s.AuthorizeURL(c.opts...)
+		ast.Error(err, c.name)
+	}
+}
+
+// TestAuthorizeURLReservedExtra
+func TestAuthorizeURLReservedExtra(t *testing.T) {
+
+	ast := assert.New(t)
+
+	// reserved by all the providers
+	for _, s := range []ISocialite{qqObj, wxObj, wxOaObj, wbObj, msObj, fbObj, lnObj, kkObj, nvObj, tgObj, twObj} {
+		_, err := s.AuthorizeURL(WithState("STATE"), WithExtra("redirect_uri", "https://evil.com/callback"))
+		if s == tgObj || s == twObj {
+			_, err = s.AuthorizeURL(WithExtra("redirect_uri", "https://evil.com/callback"))
+		}
+		ast.EqualError(err, `extra param "redirect_uri" is reserved`)
+	}
+
+	// the params of the provider, apply
+	_, err := msObj.AuthorizeURL(WithState("STATE"), WithExtra("response_mode", "fragment"))
+	ast.EqualError(err, `extra param "response_mode" is reserved`)
+	_, err = kkObj.AuthorizeURL(WithState("STATE"), WithPrompt("login"), WithExtra("prompt", "none"))
+	ast.EqualError(err, `extra param "prompt" is reserved`)
+
+	// the params of the provider, withExtra
+	_, err = wbObj.AuthorizeURL(WithState("STATE"), WithPrompt("login"), WithExtra("forcelogin", "false"))
+	ast.EqualError(err, `extra param "forcelogin" is reserved`)
+	_, err = qqObj.AuthorizeURL(WithState("STATE"), WithDisplay(QqDisplayMobile), WithExtra("display", "pc"))
+	ast.EqualError(err, `extra param "display" is reserved`)
+
+	// others are added
+	ret, err := fbObj.AuthorizeURL(WithState("STATE"), WithExtra("auth_type", "rerequest"))
+	ast.Nil(err)
+	ast.Contains(ret, "auth_type=rerequest")
+}
+
+// TestIncrementalAuthorizeURL
+func TestIncrementalAuthorizeURL(t *testing.T) {
+
+	ast := assert.New(t)
+
+	granted := []Scope{"openid", "User.Read"}
+
+	// success
+	ret, err := msObj.AuthorizeURL(WithState("STATE"), WithScopes("openid", "User.Read", "Mail.Read"), WithGrantedScopes(granted...))
+	ast.Nil(err)
+	ast.Equal("https://login.microsoftonline.com/common/oauth2/v2.0/authorize?client_id=CLIENT_ID&redirect_uri=REDIRECT_URI&response_mode=query&response_type=code&scope=Mail.Read&state=STATE", ret)
+
+	// the granted scopes are requested again by the providers without incremental consent
+	ret, err = qqObj.AuthorizeURL(WithState("STATE"), WithScopes(QqScopeGetUserInfo, QqScopeListAlbum), WithGrantedScopes(QqScopeGetUserInfo))
+	ast.Nil(err)
+	ast.Equal("https://graph.qq.com/oauth2.0/authorize?client_id=test_app_id&redirect_uri=http%3A%2F%2Flocalhost%2Fredirect_uri&response_type=code&scope=get_user_info%2Clist_album&state=STATE", ret)
+
+	// weibo forces the user to authorize again
+	ret, err = wbObj.AuthorizeURL(WithState("STATE"), WithScopes(WbScopeEmail), WithGrantedScopes(WbScopeDirectMessagesRead))
+	ast.Nil(err)
+	ast.Equal("https://api.weibo.com/oauth2/authorize?client_id=CLIENT_ID&forcelogin=true&redirect_uri=REDIRECT_URI&scope=direct_messages_read%2Cemail&state=STATE", ret)
+
+	// fail
+	_, err = msObj.AuthorizeURL(WithState("STATE"), WithScopes("openid"), WithGrantedScopes(granted...))
+	ast.Equal(ErrScopesGranted, err)
+
+	// all covers the others
+	_, err = wbObj.AuthorizeURL(WithState("STATE"), WithScopes(WbScopeEmail), WithGrantedScopes(WbScopeAll))
+	ast.Equal(ErrScopesGranted, err)
+	_, err = qqObj.AuthorizeURL(WithState("STATE"), WithScopes("get_info"), WithGrantedScopes(QqScopeAll))
+	ast.Equal(ErrScopesGranted, err)
+
+	_, err = nvObj.AuthorizeURL(WithState("STATE"), WithGrantedScopes(granted...))
+	ast.EqualError(err, "granted_scopes is not supported")
+}
@@ -9,6 +9,9 @@ type ISocialite interface {
 	// GetAuthorizeURL get authorize url
 	GetAuthorizeURL(args ...string) string
 
+	// AuthorizeURL get authorize url with options, an error is returned for invalid or unsupported ones
+	AuthorizeURL(opts ...AuthOption) (string, error)
+
 	// Token get token
 	Token(code string) (interface{}, error)
 
@@ -41,6 +44,11 @@ func (d *Default) GetAuthorizeURL(args ...string) string {
 	return "invalid"
 }
 
+// AuthorizeURL get authorize url
+func (d *Default) AuthorizeURL(opts ...AuthOption) (string, error) {
+	return "", errors.New("invalid")
+}
+
 // Token token
 func (d *Default) Token(code string) (interface{}, error) {
 	return nil, errors.New("invalid")
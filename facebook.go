@@ -0,0 +1,345 @@
+package socialite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	"strings"
+)
+
+const (
+	fbAuthorizeURL = "https://www.facebook.com/%s/dialog/oauth"
+	fbGraphURL     = "https://graph.facebook.com/%s/%s"
+	fbVersion      = "v8.0"
+	fbFields       = "id,name,email,picture"
+
+	fbGrantTypeExchange = "fb_exchange_token"
+)
+
+// Facebook struct
+// @doc: https://developers.facebook.com/docs/facebook-login/manually-build-a-login-flow
+type Facebook struct {
+	AppID       string
+	AppSecret   string
+	RedirectURL string
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	// RedirectStore keeps the per-request redirect url by state for TokenWithState
+	RedirectStore RedirectStore
+	// Version graph api version, e.g. v8.0
+	Version string
+	// Fields fields of /me, e.g. id, name, email
+	Fields      []string
+	HTTPRequest *utils.HTTPClient
+}
+
+// fbRespError response of err
+type fbRespError struct {
+	Error struct {
+		Message      string `json:"message"`
+		Type         string `json:"type"`
+		Code         int    `json:"code"`
+		ErrorSubcode int    `json:"error_subcode"`
+		FbtraceID    string `json:"fbtrace_id"`
+	} `json:"error"`
+}
+
+// FbRespToken response of token
+type FbRespToken struct {
+	fbRespError
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// FbUserInfo user info, fields depend on Facebook.Fields
+type FbUserInfo struct {
+	fbRespError
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Picture   struct {
+		Data struct {
+			Height       int    `json:"height"`
+			Width        int    `json:"width"`
+			IsSilhouette bool   `json:"is_silhouette"`
+			URL          string `json:"url"`
+		} `json:"data"`
+	} `json:"picture"`
+}
+
+// FbDebugToken response of debug_token
+type FbDebugToken struct {
+	fbRespError
+	Data FbDebugTokenData `json:"data"`
+}
+
+// FbDebugTokenData metadata of the inspected token
+type FbDebugTokenData struct {
+	AppID               string   `json:"app_id"`
+	Type                string   `json:"type"`
+	Application         string   `json:"application"`
+	DataAccessExpiresAt int64    `json:"data_access_expires_at"`
+	ExpiresAt           int64    `json:"expires_at"`
+	IsValid             bool     `json:"is_valid"`
+	IssuedAt            int64    `json:"issued_at"`
+	Scopes              []string `json:"scopes"`
+	UserID              string   `json:"user_id"`
+	Error               struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Subcode int    `json:"subcode"`
+	} `json:"error"`
+}
+
+// GrantedScopes granted scopes, compare them with the requested ones to find the declined
+func (d *FbDebugTokenData) GrantedScopes() []Scope {
+	return ToScopes(d.Scopes...)
+}
+
+// version graph api version
+func (f *Facebook) version() string {
+	if f.Version == "" {
+		return fbVersion
+	}
+	return f.Version
+}
+
+// graphURL versioned graph api url
+func (f *Facebook) graphURL(path string) string {
+	return fmt.Sprintf(fbGraphURL, f.version(), path)
+}
+
+// appAccessToken app access token, used to inspect tokens
+func (f *Facebook) appAccessToken() string {
+	return f.AppID + "|" + f.AppSecret
+}
+
+// appSecretProof sha256 hmac of the access token keyed with the app secret
+// @doc: https://developers.facebook.com/docs/graph-api/securing-requests
+func (f *Facebook) appSecretProof(accessToken string) string {
+	return utils.HmacSha256Hex(f.AppSecret, accessToken)
+}
+
+// graphGet get a graph api, appsecret_proof is attached automatically
+func (f *Facebook) graphGet(url, accessToken string, params map[string]string, ret interface{}) error {
+
+	if params == nil {
+		params = make(map[string]string, 2)
+	}
+	params["access_token"] = accessToken
+	params["appsecret_proof"] = f.appSecretProof(accessToken)
+
+	if err := f.HTTPRequest.HTTPGet(url, params); err != nil {
+		return err
+	}
+	return f.HTTPRequest.GetResponseJSON(ret)
+}
+
+// GetAuthorizeURL get authorize url, args: state, scope (comma separated)
+func (f *Facebook) GetAuthorizeURL(args ...string) string {
+
+	params := map[string]string{
+		"client_id":     f.AppID,
+		"redirect_uri":  f.RedirectURL,
+		"response_type": "code",
+	}
+
+	length := len(args)
+	if length >= 1 {
+		params["state"] = args[0]
+		if length >= 2 && args[1] != "" {
+			params["scope"] = args[1]
+		}
+	}
+
+	return fmt.Sprintf("%s?%s", fmt.Sprintf(fbAuthorizeURL, f.version()), utils.QuerySortByKeyStr2(params))
+}
+
+// AuthorizeURL get authorize url, display e.g. popup, extra e.g. auth_type=rerequest
+func (f *Facebook) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authState, authScopes, authDisplay, authRedirectURL, authGranted); err != nil {
+		return "", err
+	}
+	scope, err := o.joinScopes(FacebookScopeCatalog)
+	if err != nil {
+		return "", err
+	}
+	if err := checkRedirectURL(o.RedirectURL, f.RedirectURL, f.RedirectAllowlist); err != nil {
+		return "", err
+	}
+
+	params, err := o.apply(map[string]string{
+		"client_id":     f.AppID,
+		"redirect_uri":  o.redirectURL(f.RedirectURL),
+		"response_type": "code",
+	}, map[string]string{
+		"state":   o.State,
+		"scope":   scope,
+		"display": o.Display,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := bindRedirectURL(f.RedirectStore, o.State, o.RedirectURL, f.RedirectURL); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s?%s", fmt.Sprintf(fbAuthorizeURL, f.version()), utils.QuerySortByKeyStr2(params)), nil
+}
+
+// Token get token
+func (f *Facebook) Token(code string) (interface{}, error) {
+	return f.doToken(f.graphURL("oauth/access_token"), code)
+}
+
+// doToken handle
+func (f *Facebook) doToken(url, code string) (*FbRespToken, error) {
+	return f.doTokenWithRedirectURL(url, code, f.RedirectURL)
+}
+
+// TokenWithRedirectURL get token with the redirect url of the authorize url,
+// it must be RedirectURL or allowed by RedirectAllowlist
+func (f *Facebook) TokenWithRedirectURL(code, redirectURL string) (interface{}, error) {
+
+	if redirectURL == "" {
+		redirectURL = f.RedirectURL
+	}
+	if err := checkRedirectURL(redirectURL, f.RedirectURL, f.RedirectAllowlist); err != nil {
+		return nil, err
+	}
+	return f.doTokenWithRedirectURL(f.graphURL("oauth/access_token"), code, redirectURL)
+}
+
+// TokenWithState get token with the redirect url bound to the state by the authorize url, see RedirectStore
+func (f *Facebook) TokenWithState(code, state string) (interface{}, error) {
+	redirectURL, err := boundRedirectURL(f.RedirectStore, state, f.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return f.TokenWithRedirectURL(code, redirectURL)
+}
+
+// doTokenWithRedirectURL handle
+func (f *Facebook) doTokenWithRedirectURL(url, code, redirectURL string) (*FbRespToken, error) {
+
+	params := map[string]string{
+		"client_id":     f.AppID,
+		"client_secret": f.AppSecret,
+		"redirect_uri":  redirectURL,
+		"code":          code,
+	}
+
+	if err := f.HTTPRequest.HTTPGet(url, params, utils.NonIdempotent()); err != nil {
+		return nil, err
+	}
+
+	ret := new(FbRespToken)
+	if err := f.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// RefreshToken facebook has no refresh token, a short-lived token is exchanged for a long-lived one
+func (f *Facebook) RefreshToken(refreshToken string) (interface{}, error) {
+	return f.LongLivedToken(refreshToken)
+}
+
+// LongLivedToken exchange a short-lived token for a long-lived (about 60 days) one
+// @doc: https://developers.facebook.com/docs/facebook-login/access-tokens/refreshing
+func (f *Facebook) LongLivedToken(accessToken string) (*FbRespToken, error) {
+	return f.doLongLivedToken(f.graphURL("oauth/access_token"), accessToken)
+}
+
+// doLongLivedToken handle
+func (f *Facebook) doLongLivedToken(url, accessToken string) (*FbRespToken, error) {
+
+	params := map[string]string{
+		"grant_type":        fbGrantTypeExchange,
+		"client_id":         f.AppID,
+		"client_secret":     f.AppSecret,
+		"fb_exchange_token": accessToken,
+	}
+
+	if err := f.HTTPRequest.HTTPGet(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(FbRespToken)
+	if err := f.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetMe get me
+func (f *Facebook) GetMe(accessToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetUserInfo get user info, openID is ignored because /me resolves the user by access token
+func (f *Facebook) GetUserInfo(accessToken, openID string) (interface{}, error) {
+	return f.doGetUserInfo(f.graphURL("me"), accessToken)
+}
+
+// doGetUserInfo handle
+func (f *Facebook) doGetUserInfo(url, accessToken string) (*FbUserInfo, error) {
+
+	fields := fbFields
+	if len(f.Fields) > 0 {
+		fields = strings.Join(f.Fields, ",")
+	}
+
+	ret := new(FbUserInfo)
+	if err := f.graphGet(url, accessToken, map[string]string{"fields": fields}, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// DebugToken inspect a token, e.g. the one sent by mobile apps
+// @doc: https://developers.facebook.com/docs/facebook-login/access-tokens/debugging-and-error-handling
+func (f *Facebook) DebugToken(inputToken string) (*FbDebugToken, error) {
+	return f.doDebugToken(f.graphURL("debug_token"), inputToken)
+}
+
+// doDebugToken handle
+func (f *Facebook) doDebugToken(url, inputToken string) (*FbDebugToken, error) {
+
+	ret := new(FbDebugToken)
+	if err := f.graphGet(url, f.appAccessToken(), map[string]string{"input_token": inputToken}, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// VerifyToken make sure the token is valid and issued for this app
+func (f *Facebook) VerifyToken(inputToken string) (*FbDebugTokenData, error) {
+	return f.doVerifyToken(f.graphURL("debug_token"), inputToken)
+}
+
+// doVerifyToken handle
+func (f *Facebook) doVerifyToken(url, inputToken string) (*FbDebugTokenData, error) {
+
+	ret, err := f.doDebugToken(url, inputToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Error.Message != "" {
+		return nil, errors.New(ret.Error.Message)
+	}
+	if !ret.Data.IsValid {
+		return &ret.Data, errors.New("token is invalid")
+	}
+	if ret.Data.AppID != f.AppID {
+		return &ret.Data, errors.New("token is not issued for this app")
+	}
+	return &ret.Data, nil
+}
@@ -0,0 +1,224 @@
+package socialite
+
+import (
+	"github.com/birjemin/socialite/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	fbHTTPClient = &utils.HTTPClient{
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	fbObj = &Facebook{
+		AppID:       "APP_ID",
+		AppSecret:   "APP_SECRET",
+		RedirectURL: "REDIRECT_URI",
+		HTTPRequest: fbHTTPClient,
+	}
+)
+
+// TestFbGetAuthorizeURL test GetAuthorizeURL
+func TestFbGetAuthorizeURL(t *testing.T) {
+
+	url1 := "https://www.facebook.com/v8.0/dialog/oauth?client_id=APP_ID&redirect_uri=REDIRECT_URI&response_type=code&state=STATE"
+	url2 := "https://www.facebook.com/v9.0/dialog/oauth?client_id=APP_ID&redirect_uri=REDIRECT_URI&response_type=code&scope=email%2Cpublic_profile&state=STATE"
+
+	ast := assert.New(t)
+
+	ast.Equal(url1, fbObj.GetAuthorizeURL("STATE"))
+
+	obj := *fbObj
+	obj.Version = "v9.0"
+	ast.Equal(url2, obj.GetAuthorizeURL("STATE", "email,public_profile"))
+	ast.Equal("https://graph.facebook.com/v9.0/me", obj.graphURL("me"))
+}
+
+// TestFbToken
+func TestFbToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"access_token":"YOUR_ACCESS_TOKEN","token_type":"bearer","expires_in":5183944}`
+		for _, v := range []string{"client_id", "client_secret", "redirect_uri", "code"} {
+			if r.FormValue(v) == "" {
+				ret = `{"error":{"message":"Invalid verification code format.","type":"OAuthException","code":100,"fbtrace_id":"TRACE"}}`
+				break
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := fbObj.doToken(ts.URL, "code")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("YOUR_ACCESS_TOKEN", ret.AccessToken)
+	ast.Equal(5183944, ret.ExpiresIn)
+
+	// fail
+	ret, err = fbObj.doToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(100, ret.Error.Code)
+}
+
+// TestFbLongLivedToken
+func TestFbLongLivedToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"access_token":"LONG_LIVED_TOKEN","token_type":"bearer","expires_in":5183944}`
+		if r.FormValue("grant_type") != "fb_exchange_token" || r.FormValue("fb_exchange_token") == "" {
+			ret = `{"error":{"message":"Invalid OAuth access token.","type":"OAuthException","code":190}}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := fbObj.doLongLivedToken(ts.URL, "SHORT_LIVED_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("LONG_LIVED_TOKEN", ret.AccessToken)
+
+	// fail
+	ret, err = fbObj.doLongLivedToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(190, ret.Error.Code)
+}
+
+// TestFbUserInfo
+func TestFbUserInfo(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"id":"10224","name":"NAME","email":"a@b.com","picture":{"data":{"height":50,"width":50,"is_silhouette":false,"url":"https://platform-lookaside.fbsbx.com/pic"}}}`
+		token := r.FormValue("access_token")
+		if token == "" || r.FormValue("appsecret_proof") != utils.HmacSha256Hex("APP_SECRET", token) {
+			ret = `{"error":{"message":"Invalid appsecret_proof provided in the API argument","type":"GraphMethodException","code":100}}`
+		} else if r.FormValue("fields") != "id,name,email,picture" {
+			ret = `{"id":"10224","name":"NAME"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := fbObj.doGetUserInfo(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("10224", ret.ID)
+	ast.Equal("a@b.com", ret.Email)
+	ast.Equal("https://platform-lookaside.fbsbx.com/pic", ret.Picture.Data.URL)
+
+	// custom fields
+	obj := *fbObj
+	obj.Fields = []string{"id", "name"}
+	ret, err = obj.doGetUserInfo(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("NAME", ret.Name)
+	ast.Equal("", ret.Email)
+
+	// fail
+	ret, err = fbObj.doGetUserInfo(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(100, ret.Error.Code)
+}
+
+// TestFbVerifyToken
+func TestFbVerifyToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var ret string
+		if r.FormValue("access_token") != "APP_ID|APP_SECRET" || r.FormValue("appsecret_proof") != utils.HmacSha256Hex("APP_SECRET", "APP_ID|APP_SECRET") {
+			ret = `{"error":{"message":"Invalid OAuth access token.","type":"OAuthException","code":190}}`
+		} else {
+			switch r.FormValue("input_token") {
+			case "VALID":
+				ret = `{"data":{"app_id":"APP_ID","type":"USER","application":"APP","expires_at":1352419328,"is_valid":true,"issued_at":1347235328,"scopes":["email","public_profile"],"user_id":"1207059"}}`
+			case "OTHER_APP":
+				ret = `{"data":{"app_id":"OTHER","type":"USER","is_valid":true,"user_id":"1207059"}}`
+			default:
+				ret = `{"data":{"app_id":"APP_ID","is_valid":false,"error":{"code":190,"message":"Session has expired","subcode":463}}}`
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := fbObj.doVerifyToken(ts.URL, "VALID")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("1207059", ret.UserID)
+	ast.Equal([]string{"email", "public_profile"}, ret.Scopes)
+
+	// fail
+	_, err = fbObj.doVerifyToken(ts.URL, "OTHER_APP")
+	ast.EqualError(err, "token is not issued for this app")
+
+	ret, err = fbObj.doVerifyToken(ts.URL, "EXPIRED")
+	ast.EqualError(err, "token is invalid")
+	ast.Equal(463, ret.Error.Subcode)
+
+	obj := *fbObj
+	obj.AppSecret = "WRONG"
+	_, err = obj.doVerifyToken(ts.URL, "VALID")
+	ast.EqualError(err, "Invalid OAuth access token.")
+}
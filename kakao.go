@@ -0,0 +1,301 @@
+package socialite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	kkAuthorizeURL = "https://kauth.kakao.com/oauth/authorize"
+	kkResponseType = "code"
+
+	kkTokenURL         = "https://kauth.kakao.com/oauth/token"
+	kkGrantTypeAuth    = "authorization_code"
+	kkGrantTypeRefresh = "refresh_token"
+
+	kkUserInfoURL = "https://kapi.kakao.com/v2/user/me"
+	kkUnlinkURL   = "https://kapi.kakao.com/v1/user/unlink"
+)
+
+// Kakao struct
+// @doc: https://developers.kakao.com/docs/latest/en/kakaologin/rest-api
+type Kakao struct {
+	// ClientID REST API key of the app
+	ClientID string
+	// ClientSecret optional, only when the client secret is enabled
+	ClientSecret string
+	RedirectURL  string
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	// RedirectStore keeps the per-request redirect url by state for TokenWithState
+	RedirectStore RedirectStore
+	// PropertyKeys property keys of user/me, e.g. kakao_account.email (default: all)
+	PropertyKeys []string
+	HTTPRequest  *utils.HTTPClient
+}
+
+// kkRespErrorToken response of err
+type kkRespErrorToken struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	ErrorCode        string `json:"error_code"`
+}
+
+// kkRespError response of api's err
+type kkRespError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// KkRespToken response of token
+type KkRespToken struct {
+	kkRespErrorToken
+	TokenType             string `json:"token_type"`
+	AccessToken           string `json:"access_token"`
+	ExpiresIn             int    `json:"expires_in"`
+	RefreshToken          string `json:"refresh_token"`
+	RefreshTokenExpiresIn int    `json:"refresh_token_expires_in"`
+	Scope                 string `json:"scope"`
+}
+
+// Scopes granted scopes, space separated
+func (t *KkRespToken) Scopes() []Scope {
+	return ParseScopes(t.Scope)
+}
+
+// KkUserInfo user info
+type KkUserInfo struct {
+	kkRespError
+	ID          int64  `json:"id"`
+	ConnectedAt string `json:"connected_at"`
+	Properties  struct {
+		Nickname       string `json:"nickname"`
+		ProfileImage   string `json:"profile_image"`
+		ThumbnailImage string `json:"thumbnail_image"`
+	} `json:"properties"`
+	KakaoAccount struct {
+		ProfileNeedsAgreement bool `json:"profile_needs_agreement"`
+		Profile               struct {
+			Nickname          string `json:"nickname"`
+			ThumbnailImageURL string `json:"thumbnail_image_url"`
+			ProfileImageURL   string `json:"profile_image_url"`
+		} `json:"profile"`
+		EmailNeedsAgreement bool   `json:"email_needs_agreement"`
+		IsEmailValid        bool   `json:"is_email_valid"`
+		IsEmailVerified     bool   `json:"is_email_verified"`
+		Email               string `json:"email"`
+		AgeRange            string `json:"age_range"`
+		Birthday            string `json:"birthday"`
+		Gender              string `json:"gender"`
+	} `json:"kakao_account"`
+}
+
+// KkRespUnlink response of unlink
+type KkRespUnlink struct {
+	kkRespError
+	ID int64 `json:"id"`
+}
+
+// GetAuthorizeURL get authorize url, args: state, scope (comma separated)
+func (k *Kakao) GetAuthorizeURL(args ...string) string {
+
+	params := map[string]string{
+		"client_id":     k.ClientID,
+		"redirect_uri":  k.RedirectURL,
+		"response_type": kkResponseType,
+	}
+
+	length := len(args)
+	if length >= 1 {
+		params["state"] = args[0]
+		if length >= 2 && args[1] != "" {
+			params["scope"] = args[1]
+		}
+	}
+
+	return fmt.Sprintf("%s?%s", kkAuthorizeURL, utils.QuerySortByKeyStr2(params))
+}
+
+// AuthorizeURL get authorize url, prompt e.g. login
+func (k *Kakao) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authState, authScopes, authRedirectURL, authLoginHint, authPrompt, authGranted); err != nil {
+		return "", err
+	}
+	scope, err := o.joinScopes(KakaoScopeCatalog)
+	if err != nil {
+		return "", err
+	}
+	if err := checkRedirectURL(o.RedirectURL, k.RedirectURL, k.RedirectAllowlist); err != nil {
+		return "", err
+	}
+
+	params, err := o.apply(map[string]string{
+		"client_id":     k.ClientID,
+		"redirect_uri":  o.redirectURL(k.RedirectURL),
+		"response_type": kkResponseType,
+	}, map[string]string{
+		"state":      o.State,
+		"scope":      scope,
+		"login_hint": o.LoginHint,
+		"prompt":     o.Prompt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := bindRedirectURL(k.RedirectStore, o.State, o.RedirectURL, k.RedirectURL); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s?%s", kkAuthorizeURL, utils.QuerySortByKeyStr2(params)), nil
+}
+
+// Token get token
+func (k *Kakao) Token(code string) (interface{}, error) {
+	return k.doToken(kkTokenURL, code)
+}
+
+// doToken handle
+func (k *Kakao) doToken(url, code string) (*KkRespToken, error) {
+	return k.doTokenWithRedirectURL(url, code, k.RedirectURL)
+}
+
+// TokenWithRedirectURL get token with the redirect url of the authorize url,
+// it must be RedirectURL or allowed by RedirectAllowlist
+func (k *Kakao) TokenWithRedirectURL(code, redirectURL string) (interface{}, error) {
+
+	if redirectURL == "" {
+		redirectURL = k.RedirectURL
+	}
+	if err := checkRedirectURL(redirectURL, k.RedirectURL, k.RedirectAllowlist); err != nil {
+		return nil, err
+	}
+	return k.doTokenWithRedirectURL(kkTokenURL, code, redirectURL)
+}
+
+// TokenWithState get token with the redirect url bound to the state by the authorize url, see RedirectStore
+func (k *Kakao) TokenWithState(code, state string) (interface{}, error) {
+	redirectURL, err := boundRedirectURL(k.RedirectStore, state, k.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return k.TokenWithRedirectURL(code, redirectURL)
+}
+
+// doTokenWithRedirectURL handle
+func (k *Kakao) doTokenWithRedirectURL(url, code, redirectURL string) (*KkRespToken, error) {
+
+	params := map[string]string{
+		"grant_type":   kkGrantTypeAuth,
+		"client_id":    k.ClientID,
+		"redirect_uri": redirectURL,
+		"code":         code,
+	}
+	if k.ClientSecret != "" {
+		params["client_secret"] = k.ClientSecret
+	}
+
+	if err := k.HTTPRequest.HTTPPost(url, params, utils.NonIdempotent()); err != nil {
+		return nil, err
+	}
+
+	ret := new(KkRespToken)
+	if err := k.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// RefreshToken refresh token
+func (k *Kakao) RefreshToken(refreshToken string) (interface{}, error) {
+	return k.doRefreshToken(kkTokenURL, refreshToken)
+}
+
+// doRefreshToken handle
+func (k *Kakao) doRefreshToken(url, refreshToken string) (*KkRespToken, error) {
+
+	params := map[string]string{
+		"grant_type":    kkGrantTypeRefresh,
+		"client_id":     k.ClientID,
+		"refresh_token": refreshToken,
+	}
+	if k.ClientSecret != "" {
+		params["client_secret"] = k.ClientSecret
+	}
+
+	if err := k.HTTPRequest.HTTPPost(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(KkRespToken)
+	if err := k.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetMe get me
+func (k *Kakao) GetMe(accessToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetUserInfo get user info, openID is ignored because user/me is resolved by access token
+func (k *Kakao) GetUserInfo(accessToken, openID string) (interface{}, error) {
+	return k.doGetUserInfo(kkUserInfoURL, accessToken)
+}
+
+// doGetUserInfo handle
+func (k *Kakao) doGetUserInfo(url, accessToken string) (*KkUserInfo, error) {
+
+	var params map[string]string
+	if len(k.PropertyKeys) > 0 {
+		keys, err := jsoniter.MarshalToString(k.PropertyKeys)
+		if err != nil {
+			return nil, err
+		}
+		params = map[string]string{
+			"property_keys": keys,
+		}
+	}
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	if err := k.HTTPRequest.HTTPGetWithHeader(url, params, headers); err != nil {
+		return nil, err
+	}
+
+	ret := new(KkUserInfo)
+	if err := k.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Unlink unlink the user from the app, the tokens are invalidated
+func (k *Kakao) Unlink(accessToken string) (*KkRespUnlink, error) {
+	return k.doUnlink(kkUnlinkURL, accessToken)
+}
+
+// doUnlink handle
+func (k *Kakao) doUnlink(url, accessToken string) (*KkRespUnlink, error) {
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	if err := k.HTTPRequest.HTTPPostWithHeader(url, nil, headers); err != nil {
+		return nil, err
+	}
+
+	ret := new(KkRespUnlink)
+	if err := k.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
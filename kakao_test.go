@@ -0,0 +1,204 @@
+package socialite
+
+import (
+	"github.com/birjemin/socialite/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	kkHTTPClient = &utils.HTTPClient{
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	kkObj = &Kakao{
+		ClientID:     "REST_API_KEY",
+		ClientSecret: "CLIENT_SECRET",
+		RedirectURL:  "REDIRECT_URI",
+		HTTPRequest:  kkHTTPClient,
+	}
+)
+
+// TestKkGetAuthorizeURL test GetAuthorizeURL
+func TestKkGetAuthorizeURL(t *testing.T) {
+
+	url1 := "https://kauth.kakao.com/oauth/authorize?client_id=REST_API_KEY&redirect_uri=REDIRECT_URI&response_type=code&state=STATE"
+	url2 := "https://kauth.kakao.com/oauth/authorize?client_id=REST_API_KEY&redirect_uri=REDIRECT_URI&response_type=code&scope=account_email%2Cgender&state=STATE"
+
+	ast := assert.New(t)
+
+	ast.Equal(url1, kkObj.GetAuthorizeURL("STATE"))
+	ast.Equal(url2, kkObj.GetAuthorizeURL("STATE", "account_email,gender"))
+}
+
+// TestKkToken
+func TestKkToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"token_type":"bearer","access_token":"YOUR_ACCESS_TOKEN","expires_in":43199,"refresh_token":"YOUR_REFRESH_TOKEN","refresh_token_expires_in":25184000,"scope":"account_email profile"}`
+		for _, v := range []string{"grant_type", "client_id", "redirect_uri", "code", "client_secret"} {
+			if r.PostFormValue(v) == "" {
+				ret = `{"error":"invalid_grant","error_description":"authorization code not found for code=","error_code":"KOE320"}`
+				break
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := kkObj.doToken(ts.URL, "code")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("YOUR_ACCESS_TOKEN", ret.AccessToken)
+	ast.Equal(25184000, ret.RefreshTokenExpiresIn)
+
+	// fail
+	ret, err = kkObj.doToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("KOE320", ret.ErrorCode)
+}
+
+// TestKkRefreshToken
+func TestKkRefreshToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"token_type":"bearer","access_token":"NEW_ACCESS_TOKEN","expires_in":43199}`
+		if r.PostFormValue("grant_type") != "refresh_token" || r.PostFormValue("refresh_token") == "" {
+			ret = `{"error":"invalid_grant","error_description":"expired_or_invalid_refresh_token","error_code":"KOE322"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := kkObj.doRefreshToken(ts.URL, "YOUR_REFRESH_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("NEW_ACCESS_TOKEN", ret.AccessToken)
+	ast.Equal("", ret.RefreshToken)
+
+	// fail
+	ret, err = kkObj.doRefreshToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("KOE322", ret.ErrorCode)
+}
+
+// TestKkUserInfo
+func TestKkUserInfo(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"id":1234567890,"connected_at":"2020-07-06T09:55:51Z","properties":{"nickname":"NICKNAME"},"kakao_account":{"profile_needs_agreement":false,"profile":{"nickname":"NICKNAME"},"email_needs_agreement":false,"is_email_valid":true,"is_email_verified":true,"email":"a@b.com"}}`
+		if r.Header.Get("Authorization") != "Bearer YOUR_ACCESS_TOKEN" {
+			ret = `{"msg":"this access token does not exist","code":-401}`
+		} else if keys := r.FormValue("property_keys"); keys != "" && keys != `["properties.nickname"]` {
+			ret = `{"msg":"invalid property_keys","code":-2}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := kkObj.doGetUserInfo(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(int64(1234567890), ret.ID)
+	ast.Equal("NICKNAME", ret.Properties.Nickname)
+	ast.Equal("a@b.com", ret.KakaoAccount.Email)
+
+	// property keys
+	obj := *kkObj
+	obj.PropertyKeys = []string{"properties.nickname"}
+	ret, err = obj.doGetUserInfo(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(0, ret.Code)
+
+	// fail
+	ret, err = kkObj.doGetUserInfo(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(-401, ret.Code)
+}
+
+// TestKkUnlink
+func TestKkUnlink(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"id":1234567890}`
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer YOUR_ACCESS_TOKEN" {
+			ret = `{"msg":"this access token does not exist","code":-401}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := kkObj.doUnlink(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(int64(1234567890), ret.ID)
+
+	// fail
+	ret, err = kkObj.doUnlink(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(-401, ret.Code)
+}
@@ -0,0 +1,290 @@
+package socialite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	"time"
+)
+
+const (
+	lnAuthorizeURL = "https://access.line.me/oauth2/v2.1/authorize"
+	lnResponseType = "code"
+	lnScope        = "profile openid"
+
+	lnTokenURL         = "https://api.line.me/oauth2/v2.1/token"
+	lnGrantTypeAuth    = "authorization_code"
+	lnGrantTypeRefresh = "refresh_token"
+
+	lnUserInfoURL = "https://api.line.me/v2/profile"
+
+	lnIssuer = "https://access.line.me"
+)
+
+const (
+	// LineBotPromptNormal show an option to add the bot as a friend in the consent screen
+	LineBotPromptNormal = "normal"
+	// LineBotPromptAggressive open a new screen to add the bot as a friend after the consent screen
+	LineBotPromptAggressive = "aggressive"
+)
+
+// Line struct
+// @doc: https://developers.line.biz/en/docs/line-login/integrate-line-login/
+type Line struct {
+	ChannelID     string
+	ChannelSecret string
+	RedirectURL   string
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	// RedirectStore keeps the per-request redirect url by state for TokenWithState
+	RedirectStore RedirectStore
+	// BotPrompt normal or aggressive, links the LINE official account (bot) of the channel
+	BotPrompt   string
+	HTTPRequest *utils.HTTPClient
+}
+
+// lnRespErrorToken response of err
+type lnRespErrorToken struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// LnRespToken response of token
+type LnRespToken struct {
+	lnRespErrorToken
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	TokenType    string `json:"token_type"`
+}
+
+// Scopes granted scopes, space separated
+func (t *LnRespToken) Scopes() []Scope {
+	return ParseScopes(t.Scope)
+}
+
+// LnUserInfo user info
+type LnUserInfo struct {
+	Message       string `json:"message"`
+	UserID        string `json:"userId"`
+	DisplayName   string `json:"displayName"`
+	PictureURL    string `json:"pictureUrl"`
+	StatusMessage string `json:"statusMessage"`
+}
+
+// LnIDTokenClaims claims of id_token
+type LnIDTokenClaims struct {
+	Iss      string   `json:"iss"`
+	Sub      string   `json:"sub"`
+	Aud      string   `json:"aud"`
+	Exp      int64    `json:"exp"`
+	Iat      int64    `json:"iat"`
+	AuthTime int64    `json:"auth_time"`
+	Nonce    string   `json:"nonce"`
+	Amr      []string `json:"amr"`
+	Name     string   `json:"name"`
+	Picture  string   `json:"picture"`
+	Email    string   `json:"email"`
+}
+
+// GetAuthorizeURL get authorize url, args: state, scope, nonce
+func (l *Line) GetAuthorizeURL(args ...string) string {
+
+	params := map[string]string{
+		"response_type": lnResponseType,
+		"client_id":     l.ChannelID,
+		"redirect_uri":  l.RedirectURL,
+		"scope":         lnScope,
+	}
+
+	if l.BotPrompt != "" {
+		params["bot_prompt"] = l.BotPrompt
+	}
+
+	length := len(args)
+	if length >= 1 {
+		params["state"] = args[0]
+		if length >= 2 && args[1] != "" {
+			params["scope"] = args[1]
+			if length >= 3 && args[2] != "" {
+				params["nonce"] = args[2]
+			}
+		}
+	}
+
+	return fmt.Sprintf("%s?%s", lnAuthorizeURL, utils.QuerySortByKeyStr2(params))
+}
+
+// AuthorizeURL get authorize url, prompt e.g. consent, extra e.g. nonce, ui_locales
+func (l *Line) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authState, authScopes, authRedirectURL, authPrompt, authGranted); err != nil {
+		return "", err
+	}
+	scope, err := o.joinScopes(LineScopeCatalog)
+	if err != nil {
+		return "", err
+	}
+	if err := checkRedirectURL(o.RedirectURL, l.RedirectURL, l.RedirectAllowlist); err != nil {
+		return "", err
+	}
+
+	params := map[string]string{
+		"response_type": lnResponseType,
+		"client_id":     l.ChannelID,
+		"redirect_uri":  o.redirectURL(l.RedirectURL),
+		"scope":         lnScope,
+	}
+
+	params, err = o.apply(params, map[string]string{
+		"bot_prompt": l.BotPrompt,
+		"state":      o.State,
+		"scope":      scope,
+		"prompt":     o.Prompt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := bindRedirectURL(l.RedirectStore, o.State, o.RedirectURL, l.RedirectURL); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s?%s", lnAuthorizeURL, utils.QuerySortByKeyStr2(params)), nil
+}
+
+// Token get token
+func (l *Line) Token(code string) (interface{}, error) {
+	return l.doToken(lnTokenURL, code)
+}
+
+// doToken handle
+func (l *Line) doToken(url, code string) (*LnRespToken, error) {
+	return l.doTokenWithRedirectURL(url, code, l.RedirectURL)
+}
+
+// TokenWithRedirectURL get token with the redirect url of the authorize url,
+// it must be RedirectURL or allowed by RedirectAllowlist
+func (l *Line) TokenWithRedirectURL(code, redirectURL string) (interface{}, error) {
+
+	if redirectURL == "" {
+		redirectURL = l.RedirectURL
+	}
+	if err := checkRedirectURL(redirectURL, l.RedirectURL, l.RedirectAllowlist); err != nil {
+		return nil, err
+	}
+	return l.doTokenWithRedirectURL(lnTokenURL, code, redirectURL)
+}
+
+// TokenWithState get token with the redirect url bound to the state by the authorize url, see RedirectStore
+func (l *Line) TokenWithState(code, state string) (interface{}, error) {
+	redirectURL, err := boundRedirectURL(l.RedirectStore, state, l.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return l.TokenWithRedirectURL(code, redirectURL)
+}
+
+// doTokenWithRedirectURL handle
+func (l *Line) doTokenWithRedirectURL(url, code, redirectURL string) (*LnRespToken, error) {
+
+	params := map[string]string{
+		"grant_type":    lnGrantTypeAuth,
+		"code":          code,
+		"redirect_uri":  redirectURL,
+		"client_id":     l.ChannelID,
+		"client_secret": l.ChannelSecret,
+	}
+
+	if err := l.HTTPRequest.HTTPPost(url, params, utils.NonIdempotent()); err != nil {
+		return nil, err
+	}
+
+	ret := new(LnRespToken)
+	if err := l.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// RefreshToken refresh token
+func (l *Line) RefreshToken(refreshToken string) (interface{}, error) {
+	return l.doRefreshToken(lnTokenURL, refreshToken)
+}
+
+// doRefreshToken handle
+func (l *Line) doRefreshToken(url, refreshToken string) (*LnRespToken, error) {
+
+	params := map[string]string{
+		"grant_type":    lnGrantTypeRefresh,
+		"refresh_token": refreshToken,
+		"client_id":     l.ChannelID,
+		"client_secret": l.ChannelSecret,
+	}
+
+	if err := l.HTTPRequest.HTTPPost(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(LnRespToken)
+	if err := l.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetMe get me
+func (l *Line) GetMe(accessToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetUserInfo get user info, openID is ignored because the profile is resolved by access token
+func (l *Line) GetUserInfo(accessToken, openID string) (interface{}, error) {
+	return l.doGetUserInfo(lnUserInfoURL, accessToken)
+}
+
+// doGetUserInfo handle
+func (l *Line) doGetUserInfo(url, accessToken string) (*LnUserInfo, error) {
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	if err := l.HTTPRequest.HTTPGetWithHeader(url, nil, headers); err != nil {
+		return nil, err
+	}
+
+	ret := new(LnUserInfo)
+	if err := l.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ParseIDToken verify the id_token (HS256 signed with the channel secret) and validate its claims
+// nonce is checked only when it is not empty
+// @doc: https://developers.line.biz/en/docs/line-login/verify-id-token/
+func (l *Line) ParseIDToken(idToken, nonce string) (*LnIDTokenClaims, error) {
+
+	claims := new(LnIDTokenClaims)
+	if err := utils.VerifyJWTHS256(idToken, l.ChannelSecret, claims); err != nil {
+		return nil, err
+	}
+
+	if claims.Iss != lnIssuer {
+		return claims, errors.New("id_token issuer is invalid")
+	}
+	if claims.Aud != l.ChannelID {
+		return claims, errors.New("id_token audience is invalid")
+	}
+	if claims.Exp < time.Now().Unix() {
+		return claims, errors.New("id_token is expired")
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return claims, errors.New("id_token nonce is invalid")
+	}
+	return claims, nil
+}
@@ -0,0 +1,197 @@
+package socialite
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/birjemin/socialite/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	lnHTTPClient = &utils.HTTPClient{
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	lnObj = &Line{
+		ChannelID:     "1234567890",
+		ChannelSecret: "CHANNEL_SECRET",
+		RedirectURL:   "REDIRECT_URI",
+		BotPrompt:     LineBotPromptNormal,
+		HTTPRequest:   lnHTTPClient,
+	}
+)
+
+// lnIDToken build a HS256 signed id_token
+func lnIDToken(secret string, claims map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"HS256"}`))
+	payload, _ := json.Marshal(claims)
+	signing := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signing + "." + base64.RawURLEncoding.EncodeToString(utils.HmacSha256([]byte(secret), []byte(signing)))
+}
+
+// TestLnGetAuthorizeURL test GetAuthorizeURL
+func TestLnGetAuthorizeURL(t *testing.T) {
+
+	url1 := "https://access.line.me/oauth2/v2.1/authorize?bot_prompt=normal&client_id=1234567890&redirect_uri=REDIRECT_URI&response_type=code&scope=profile+openid&state=STATE"
+	url2 := "https://access.line.me/oauth2/v2.1/authorize?bot_prompt=normal&client_id=1234567890&nonce=NONCE&redirect_uri=REDIRECT_URI&response_type=code&scope=profile+openid+email&state=STATE"
+
+	ast := assert.New(t)
+
+	ast.Equal(url1, lnObj.GetAuthorizeURL("STATE"))
+	ast.Equal(url2, lnObj.GetAuthorizeURL("STATE", "profile openid email", "NONCE"))
+}
+
+// TestLnToken
+func TestLnToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"access_token":"YOUR_ACCESS_TOKEN","expires_in":2592000,"id_token":"ID_TOKEN","refresh_token":"YOUR_REFRESH_TOKEN","scope":"profile openid","token_type":"Bearer"}`
+		for _, v := range []string{"grant_type", "code", "redirect_uri", "client_id", "client_secret"} {
+			if r.PostFormValue(v) == "" {
+				ret = `{"error":"invalid_grant","error_description":"invalid authorization code"}`
+				break
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := lnObj.doToken(ts.URL, "code")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("YOUR_ACCESS_TOKEN", ret.AccessToken)
+	ast.Equal("ID_TOKEN", ret.IDToken)
+	ast.Equal(2592000, ret.ExpiresIn)
+
+	// fail
+	ret, err = lnObj.doToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("invalid_grant", ret.Error)
+}
+
+// TestLnRefreshToken
+func TestLnRefreshToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"access_token":"NEW_ACCESS_TOKEN","expires_in":2592000,"refresh_token":"NEW_REFRESH_TOKEN","scope":"profile openid","token_type":"Bearer"}`
+		if r.PostFormValue("grant_type") != "refresh_token" || r.PostFormValue("refresh_token") == "" {
+			ret = `{"error":"invalid_grant","error_description":"invalid refresh token"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := lnObj.doRefreshToken(ts.URL, "YOUR_REFRESH_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("NEW_ACCESS_TOKEN", ret.AccessToken)
+
+	// fail
+	ret, err = lnObj.doRefreshToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("invalid_grant", ret.Error)
+}
+
+// TestLnUserInfo
+func TestLnUserInfo(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"userId":"U4af4980629","displayName":"Brown","pictureUrl":"https://profile.line-scdn.net/abcdefghijklmn","statusMessage":"Hello, LINE!"}`
+		if r.Header.Get("Authorization") != "Bearer YOUR_ACCESS_TOKEN" {
+			ret = `{"message":"The access token expired"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := lnObj.doGetUserInfo(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("U4af4980629", ret.UserID)
+	ast.Equal("Brown", ret.DisplayName)
+
+	// fail
+	ret, err = lnObj.doGetUserInfo(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("The access token expired", ret.Message)
+}
+
+// TestLnParseIDToken
+func TestLnParseIDToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := func(iss, aud string, exp int64) map[string]interface{} {
+		return map[string]interface{}{"iss": iss, "sub": "U1234567890abcdef", "aud": aud, "exp": exp, "nonce": "NONCE", "name": "Taro Line"}
+	}
+
+	// success
+	ret, err := lnObj.ParseIDToken(lnIDToken("CHANNEL_SECRET", claims(lnIssuer, "1234567890", exp)), "NONCE")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("U1234567890abcdef", ret.Sub)
+	ast.Equal("Taro Line", ret.Name)
+
+	// fail
+	_, err = lnObj.ParseIDToken(lnIDToken("WRONG", claims(lnIssuer, "1234567890", exp)), "")
+	ast.EqualError(err, "jwt signature is invalid")
+	_, err = lnObj.ParseIDToken(lnIDToken("CHANNEL_SECRET", claims("https://evil.com", "1234567890", exp)), "")
+	ast.EqualError(err, "id_token issuer is invalid")
+	_, err = lnObj.ParseIDToken(lnIDToken("CHANNEL_SECRET", claims(lnIssuer, "0987654321", exp)), "")
+	ast.EqualError(err, "id_token audience is invalid")
+	_, err = lnObj.ParseIDToken(lnIDToken("CHANNEL_SECRET", claims(lnIssuer, "1234567890", time.Now().Add(-time.Hour).Unix())), "")
+	ast.EqualError(err, "id_token is expired")
+	_, err = lnObj.ParseIDToken(lnIDToken("CHANNEL_SECRET", claims(lnIssuer, "1234567890", exp)), "OTHER")
+	ast.EqualError(err, "id_token nonce is invalid")
+}
@@ -0,0 +1,347 @@
+package socialite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	"strings"
+	"time"
+)
+
+const (
+	msAuthorizeURL     = "https://login.microsoftonline.com/%s/oauth2/v2.0/authorize"
+	msResponseType     = "code"
+	msResponseMode     = "query"
+	msScope            = "openid profile email offline_access User.Read"
+	msTokenURL         = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	msGrantTypeAuth    = "authorization_code"
+	msGrantTypeRefresh = "refresh_token"
+
+	msUserInfoURL = "https://graph.microsoft.com/v1.0/me"
+
+	// issuer template of the multi-tenant endpoints, {tenantid} is replaced by the tid claim
+	msIssuerTemplate = "https://login.microsoftonline.com/{tenantid}/v2.0"
+	// tenant id of all personal microsoft accounts
+	msConsumerTenantID = "9188040d-6c67-4c5b-b112-36a304b66dad"
+)
+
+const (
+	// MsTenantCommon both work/school and personal accounts
+	MsTenantCommon = "common"
+	// MsTenantOrganizations work/school accounts only
+	MsTenantOrganizations = "organizations"
+	// MsTenantConsumers personal accounts only
+	MsTenantConsumers = "consumers"
+)
+
+// Microsoft struct
+// @doc: https://docs.microsoft.com/en-us/azure/active-directory/develop/v2-oauth2-auth-code-flow
+type Microsoft struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	// RedirectStore keeps the per-request redirect url by state for TokenWithState
+	RedirectStore RedirectStore
+	// Tenant common, organizations, consumers or a specific tenant id (default: common)
+	Tenant      string
+	HTTPRequest *utils.HTTPClient
+}
+
+// msRespErrorToken response of err
+type msRespErrorToken struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	ErrorCodes       []int  `json:"error_codes"`
+	TraceID          string `json:"trace_id"`
+	CorrelationID    string `json:"correlation_id"`
+}
+
+// MsRespToken response of token
+type MsRespToken struct {
+	msRespErrorToken
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	ExpiresIn    int    `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+}
+
+// Scopes granted scopes, space separated
+func (t *MsRespToken) Scopes() []Scope {
+	return ParseScopes(t.Scope)
+}
+
+// msGraphError response of graph's err
+type msGraphError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// MsUserInfo user info
+type MsUserInfo struct {
+	msGraphError
+	ID                string   `json:"id"`
+	DisplayName       string   `json:"displayName"`
+	GivenName         string   `json:"givenName"`
+	Surname           string   `json:"surname"`
+	UserPrincipalName string   `json:"userPrincipalName"`
+	Mail              string   `json:"mail"`
+	JobTitle          string   `json:"jobTitle"`
+	MobilePhone       string   `json:"mobilePhone"`
+	OfficeLocation    string   `json:"officeLocation"`
+	PreferredLanguage string   `json:"preferredLanguage"`
+	BusinessPhones    []string `json:"businessPhones"`
+}
+
+// MsIDTokenClaims claims of id_token
+type MsIDTokenClaims struct {
+	Aud               string `json:"aud"`
+	Iss               string `json:"iss"`
+	Iat               int64  `json:"iat"`
+	Nbf               int64  `json:"nbf"`
+	Exp               int64  `json:"exp"`
+	Name              string `json:"name"`
+	Email             string `json:"email"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+	Oid               string `json:"oid"`
+	Sub               string `json:"sub"`
+	Tid               string `json:"tid"`
+	Ver               string `json:"ver"`
+}
+
+// tenant tenant of the endpoints
+func (m *Microsoft) tenant() string {
+	if m.Tenant == "" {
+		return MsTenantCommon
+	}
+	return m.Tenant
+}
+
+// GetAuthorizeURL get authorize url, args: state, scope, prompt
+func (m *Microsoft) GetAuthorizeURL(args ...string) string {
+
+	params := map[string]string{
+		"client_id":     m.ClientID,
+		"response_type": msResponseType,
+		"response_mode": msResponseMode,
+		"redirect_uri":  m.RedirectURL,
+		"scope":         msScope,
+	}
+
+	length := len(args)
+	if length >= 1 {
+		params["state"] = args[0]
+		if length >= 2 && args[1] != "" {
+			params["scope"] = args[1]
+			if length >= 3 {
+				params["prompt"] = args[2]
+			}
+		}
+	}
+
+	return fmt.Sprintf("%s?%s", fmt.Sprintf(msAuthorizeURL, m.tenant()), utils.QuerySortByKeyStr2(params))
+}
+
+// AuthorizeURL get authorize url, extra e.g. domain_hint
+func (m *Microsoft) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authState, authScopes, authRedirectURL, authLoginHint, authPrompt, authGranted); err != nil {
+		return "", err
+	}
+	scope, err := o.joinScopes(MicrosoftScopeCatalog)
+	if err != nil {
+		return "", err
+	}
+	if err := checkRedirectURL(o.RedirectURL, m.RedirectURL, m.RedirectAllowlist); err != nil {
+		return "", err
+	}
+
+	params, err := o.apply(map[string]string{
+		"client_id":     m.ClientID,
+		"response_type": msResponseType,
+		"response_mode": msResponseMode,
+		"redirect_uri":  o.redirectURL(m.RedirectURL),
+		"scope":         msScope,
+	}, map[string]string{
+		"state":      o.State,
+		"scope":      scope,
+		"login_hint": o.LoginHint,
+		"prompt":     o.Prompt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := bindRedirectURL(m.RedirectStore, o.State, o.RedirectURL, m.RedirectURL); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s?%s", fmt.Sprintf(msAuthorizeURL, m.tenant()), utils.QuerySortByKeyStr2(params)), nil
+}
+
+// Token get token
+func (m *Microsoft) Token(code string) (interface{}, error) {
+	return m.doToken(fmt.Sprintf(msTokenURL, m.tenant()), code)
+}
+
+// doToken handle
+func (m *Microsoft) doToken(url, code string) (*MsRespToken, error) {
+	return m.doTokenWithRedirectURL(url, code, m.RedirectURL)
+}
+
+// TokenWithRedirectURL get token with the redirect url of the authorize url,
+// it must be RedirectURL or allowed by RedirectAllowlist
+func (m *Microsoft) TokenWithRedirectURL(code, redirectURL string) (interface{}, error) {
+
+	if redirectURL == "" {
+		redirectURL = m.RedirectURL
+	}
+	if err := checkRedirectURL(redirectURL, m.RedirectURL, m.RedirectAllowlist); err != nil {
+		return nil, err
+	}
+	return m.doTokenWithRedirectURL(fmt.Sprintf(msTokenURL, m.tenant()), code, redirectURL)
+}
+
+// TokenWithState get token with the redirect url bound to the state by the authorize url, see RedirectStore
+func (m *Microsoft) TokenWithState(code, state string) (interface{}, error) {
+	redirectURL, err := boundRedirectURL(m.RedirectStore, state, m.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return m.TokenWithRedirectURL(code, redirectURL)
+}
+
+// doTokenWithRedirectURL handle
+func (m *Microsoft) doTokenWithRedirectURL(url, code, redirectURL string) (*MsRespToken, error) {
+
+	params := map[string]string{
+		"grant_type":    msGrantTypeAuth,
+		"client_id":     m.ClientID,
+		"client_secret": m.ClientSecret,
+		"redirect_uri":  redirectURL,
+		"code":          code,
+	}
+
+	if err := m.HTTPRequest.HTTPPost(url, params, utils.NonIdempotent()); err != nil {
+		return nil, err
+	}
+
+	ret := new(MsRespToken)
+	if err := m.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// RefreshToken refresh token
+func (m *Microsoft) RefreshToken(refreshToken string) (interface{}, error) {
+	return m.doRefreshToken(fmt.Sprintf(msTokenURL, m.tenant()), refreshToken)
+}
+
+// doRefreshToken handle
+func (m *Microsoft) doRefreshToken(url, refreshToken string) (*MsRespToken, error) {
+
+	params := map[string]string{
+		"grant_type":    msGrantTypeRefresh,
+		"client_id":     m.ClientID,
+		"client_secret": m.ClientSecret,
+		"refresh_token": refreshToken,
+	}
+
+	if err := m.HTTPRequest.HTTPPost(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(MsRespToken)
+	if err := m.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetMe get me (the oid claim of the id_token identifies the user)
+func (m *Microsoft) GetMe(accessToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetUserInfo get user info, openID is ignored because graph resolves the user by access token
+func (m *Microsoft) GetUserInfo(accessToken, openID string) (interface{}, error) {
+	return m.doGetUserInfo(msUserInfoURL, accessToken)
+}
+
+// doGetUserInfo handle
+func (m *Microsoft) doGetUserInfo(url, accessToken string) (*MsUserInfo, error) {
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	if err := m.HTTPRequest.HTTPGetWithHeader(url, nil, headers); err != nil {
+		return nil, err
+	}
+
+	ret := new(MsUserInfo)
+	if err := m.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ParseIDToken parse the id_token of the token response and validate its aud, iss and exp
+// the signature is not verified, the id_token must come directly from the token endpoint over tls
+func (m *Microsoft) ParseIDToken(idToken string) (*MsIDTokenClaims, error) {
+
+	claims := new(MsIDTokenClaims)
+	if err := utils.DecodeJWT(idToken, claims); err != nil {
+		return nil, err
+	}
+
+	if claims.Aud != m.ClientID {
+		return claims, errors.New("id_token audience is invalid")
+	}
+
+	if err := m.validateIssuer(claims); err != nil {
+		return claims, err
+	}
+
+	if claims.Exp < time.Now().Unix() {
+		return claims, errors.New("id_token is expired")
+	}
+	return claims, nil
+}
+
+// validateIssuer the issuer is always tenant specific, even for the multi-tenant endpoints
+func (m *Microsoft) validateIssuer(claims *MsIDTokenClaims) error {
+
+	if claims.Tid == "" {
+		return errors.New("id_token tid is missing")
+	}
+
+	if claims.Iss != strings.Replace(msIssuerTemplate, "{tenantid}", claims.Tid, 1) {
+		return errors.New("id_token issuer is invalid")
+	}
+
+	switch tenant := m.tenant(); tenant {
+	case MsTenantCommon:
+	case MsTenantOrganizations:
+		if claims.Tid == msConsumerTenantID {
+			return errors.New("id_token tenant is a personal account")
+		}
+	case MsTenantConsumers:
+		if claims.Tid != msConsumerTenantID {
+			return errors.New("id_token tenant is not a personal account")
+		}
+	default:
+		if !strings.EqualFold(claims.Tid, tenant) {
+			return errors.New("id_token tenant is invalid")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package socialite
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/birjemin/socialite/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	msHTTPClient = &utils.HTTPClient{
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	msObj = &Microsoft{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		RedirectURL:  "REDIRECT_URI",
+		HTTPRequest:  msHTTPClient,
+	}
+)
+
+// msIDToken build an unsigned id_token
+func msIDToken(claims map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims)
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+// TestMsGetAuthorizeURL test GetAuthorizeURL
+func TestMsGetAuthorizeURL(t *testing.T) {
+
+	url1 := "https://login.microsoftonline.com/common/oauth2/v2.0/authorize?client_id=CLIENT_ID&redirect_uri=REDIRECT_URI&response_mode=query&response_type=code&scope=openid+profile+email+offline_access+User.Read&state=STATE"
+	url2 := "https://login.microsoftonline.com/organizations/oauth2/v2.0/authorize?client_id=CLIENT_ID&prompt=consent&redirect_uri=REDIRECT_URI&response_mode=query&response_type=code&scope=openid+User.Read&state=STATE"
+
+	ast := assert.New(t)
+
+	ast.Equal(url1, msObj.GetAuthorizeURL("STATE"))
+
+	obj := *msObj
+	obj.Tenant = MsTenantOrganizations
+	ast.Equal(url2, obj.GetAuthorizeURL("STATE", "openid User.Read", "consent"))
+}
+
+// TestMsToken
+func TestMsToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"token_type":"Bearer","scope":"openid User.Read","expires_in":3599,"access_token":"YOUR_ACCESS_TOKEN","refresh_token":"YOUR_REFRESH_TOKEN","id_token":"ID_TOKEN"}`
+		for _, v := range []string{"grant_type", "client_id", "client_secret", "redirect_uri", "code"} {
+			if r.PostFormValue(v) == "" {
+				ret = `{"error":"invalid_grant","error_description":"AADSTS9002313: Invalid request.","error_codes":[9002313]}`
+				break
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := msObj.doToken(ts.URL, "code")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+
+	ast.Equal("", ret.Error)
+	ast.Equal("YOUR_ACCESS_TOKEN", ret.AccessToken)
+	ast.Equal("YOUR_REFRESH_TOKEN", ret.RefreshToken)
+	ast.Equal("ID_TOKEN", ret.IDToken)
+	ast.Equal(3599, ret.ExpiresIn)
+
+	// fail
+	ret, err = msObj.doToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("invalid_grant", ret.Error)
+	ast.Equal([]int{9002313}, ret.ErrorCodes)
+}
+
+// TestMsRefreshToken
+func TestMsRefreshToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"token_type":"Bearer","scope":"openid User.Read","expires_in":3599,"access_token":"NEW_ACCESS_TOKEN","refresh_token":"NEW_REFRESH_TOKEN"}`
+		if r.PostFormValue("grant_type") != "refresh_token" || r.PostFormValue("refresh_token") == "" {
+			ret = `{"error":"invalid_grant","error_description":"AADSTS70000: refresh token is invalid."}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := msObj.doRefreshToken(ts.URL, "YOUR_REFRESH_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("NEW_ACCESS_TOKEN", ret.AccessToken)
+	ast.Equal("NEW_REFRESH_TOKEN", ret.RefreshToken)
+
+	// fail
+	ret, err = msObj.doRefreshToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("invalid_grant", ret.Error)
+}
+
+// TestMsUserInfo
+func TestMsUserInfo(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"id":"48d31887-5fad-4d73-a9f5-3c356e68a038","displayName":"Megan Bowen","givenName":"Megan","surname":"Bowen","userPrincipalName":"MeganB@contoso.com","mail":"MeganB@contoso.com","businessPhones":["+1 412 555 0109"]}`
+		if r.Header.Get("Authorization") != "Bearer YOUR_ACCESS_TOKEN" {
+			ret = `{"error":{"code":"InvalidAuthenticationToken","message":"Access token is empty."}}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := msObj.doGetUserInfo(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("48d31887-5fad-4d73-a9f5-3c356e68a038", ret.ID)
+	ast.Equal("Megan Bowen", ret.DisplayName)
+	ast.Equal([]string{"+1 412 555 0109"}, ret.BusinessPhones)
+
+	// fail
+	ret, err = msObj.doGetUserInfo(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("InvalidAuthenticationToken", ret.Error.Code)
+}
+
+// TestMsParseIDToken
+func TestMsParseIDToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	tid := "72f988bf-86f1-41af-91ab-2d7cd011db47"
+	exp := time.Now().Add(time.Hour).Unix()
+
+	claims := func(aud, iss, tid string, exp int64) map[string]interface{} {
+		return map[string]interface{}{"aud": aud, "iss": iss, "tid": tid, "exp": exp, "oid": "OID", "name": "NAME"}
+	}
+	orgIss := "https://login.microsoftonline.com/" + tid + "/v2.0"
+	consumerIss := "https://login.microsoftonline.com/" + msConsumerTenantID + "/v2.0"
+
+	// common accepts every tenant
+	ret, err := msObj.ParseIDToken(msIDToken(claims("CLIENT_ID", orgIss, tid, exp)))
+	ast.Nil(err)
+	ast.Equal("OID", ret.Oid)
+	_, err = msObj.ParseIDToken(msIDToken(claims("CLIENT_ID", consumerIss, msConsumerTenantID, exp)))
+	ast.Nil(err)
+
+	// invalid audience, issuer and expiry
+	_, err = msObj.ParseIDToken(msIDToken(claims("OTHER", orgIss, tid, exp)))
+	ast.EqualError(err, "id_token audience is invalid")
+	_, err = msObj.ParseIDToken(msIDToken(claims("CLIENT_ID", orgIss, msConsumerTenantID, exp)))
+	ast.EqualError(err, "id_token issuer is invalid")
+	_, err = msObj.ParseIDToken(msIDToken(claims("CLIENT_ID", orgIss, tid, time.Now().Add(-time.Hour).Unix())))
+	ast.EqualError(err, "id_token is expired")
+
+	// organizations
+	obj := *msObj
+	obj.Tenant = MsTenantOrganizations
+	_, err = obj.ParseIDToken(msIDToken(claims("CLIENT_ID", orgIss, tid, exp)))
+	ast.Nil(err)
+	_, err = obj.ParseIDToken(msIDToken(claims("CLIENT_ID", consumerIss, msConsumerTenantID, exp)))
+	ast.Error(err)
+
+	// consumers
+	obj.Tenant = MsTenantConsumers
+	_, err = obj.ParseIDToken(msIDToken(claims("CLIENT_ID", orgIss, tid, exp)))
+	ast.Error(err)
+
+	// specific tenant
+	obj.Tenant = tid
+	_, err = obj.ParseIDToken(msIDToken(claims("CLIENT_ID", orgIss, tid, exp)))
+	ast.Nil(err)
+	_, err = obj.ParseIDToken(msIDToken(claims("CLIENT_ID", consumerIss, msConsumerTenantID, exp)))
+	ast.EqualError(err, "id_token tenant is invalid")
+}
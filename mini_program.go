@@ -0,0 +1,78 @@
+package socialite
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/birjemin/socialite/utils"
+	jsoniter "github.com/json-iterator/go"
+	"time"
+)
+
+// shared by the wechat and qq mini programs, both follow the same protocol
+
+const (
+	miniProgramGrantType = "authorization_code"
+)
+
+// miniProgramCode2Session exchange the code of login for the session into ret
+func miniProgramCode2Session(h *utils.HTTPClient, url, appID, appSecret, code string, ret interface{}) error {
+
+	params := map[string]string{
+		"appid":      appID,
+		"secret":     appSecret,
+		"js_code":    code,
+		"grant_type": miniProgramGrantType,
+	}
+
+	if err := h.HTTPGet(url, params, utils.NonIdempotent()); err != nil {
+		return err
+	}
+	return h.GetResponseJSON(ret)
+}
+
+// miniProgramVerifyRawData sha1(rawData + session_key) must equal to signature
+func miniProgramVerifyRawData(rawData, signature, sessionKey string) bool {
+	sum := sha1.Sum([]byte(rawData + sessionKey))
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(signature)) == 1
+}
+
+// miniProgramDecrypt aes-128-cbc decrypt, base64 encoded session_key is the key
+// the watermark appid must be the one of the mini program, its timestamp is checked when maxAge > 0
+func miniProgramDecrypt(appID string, maxAge time.Duration, sessionKey, encryptedData, iv string, v interface{}) error {
+
+	key, err := base64.StdEncoding.DecodeString(sessionKey)
+	if err != nil || len(key) != 16 {
+		return errors.New("session_key is invalid")
+	}
+	ivb, err := base64.StdEncoding.DecodeString(iv)
+	if err != nil {
+		return errors.New("iv is invalid")
+	}
+	data, err := base64.StdEncoding.DecodeString(encryptedData)
+	if err != nil {
+		return errors.New("encryptedData is invalid")
+	}
+
+	plain, err := utils.AesCBCDecrypt(key, ivb, data)
+	if err != nil {
+		return err
+	}
+
+	watermark := struct {
+		Watermark WxMiniWatermark `json:"watermark"`
+	}{}
+	if err := jsoniter.Unmarshal(plain, &watermark); err != nil {
+		return err
+	}
+	if watermark.Watermark.AppID != appID {
+		return errors.New("watermark appid is invalid")
+	}
+	if maxAge > 0 && time.Since(time.Unix(watermark.Watermark.Timestamp, 0)) > maxAge {
+		return errors.New("watermark is expired")
+	}
+
+	return jsoniter.Unmarshal(plain, v)
+}
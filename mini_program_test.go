@@ -0,0 +1,48 @@
+package socialite
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var (
+	miniSessionKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	miniIV         = base64.StdEncoding.EncodeToString([]byte("fedcba9876543210"))
+)
+
+// miniEncrypt encrypt the data as the mini program platform does
+func miniEncrypt(plain string) string {
+	key, _ := base64.StdEncoding.DecodeString(miniSessionKey)
+	iv, _ := base64.StdEncoding.DecodeString(miniIV)
+
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	data := append([]byte(plain), bytes.Repeat([]byte{byte(pad)}, pad)...)
+
+	block, _ := aes.NewCipher(key)
+	ret := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ret, data)
+	return base64.StdEncoding.EncodeToString(ret)
+}
+
+// TestMiniProgramDecrypt
+func TestMiniProgramDecrypt(t *testing.T) {
+
+	ast := assert.New(t)
+
+	v := struct {
+		Foo string `json:"foo"`
+	}{}
+
+	data := miniEncrypt(`{"foo":"bar","watermark":{"appid":"APPID","timestamp":1}}`)
+	ast.Nil(miniProgramDecrypt("APPID", 0, miniSessionKey, data, miniIV, &v))
+	ast.Equal("bar", v.Foo)
+
+	// the data of another app
+	ast.Error(miniProgramDecrypt("OTHER_APPID", 0, miniSessionKey, data, miniIV, &v))
+	// expired
+	ast.Error(miniProgramDecrypt("APPID", 1, miniSessionKey, data, miniIV, &v))
+}
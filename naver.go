@@ -0,0 +1,196 @@
+package socialite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+)
+
+const (
+	nvAuthorizeURL = "https://nid.naver.com/oauth2.0/authorize"
+	nvResponseType = "code"
+
+	nvTokenURL         = "https://nid.naver.com/oauth2.0/token"
+	nvGrantTypeAuth    = "authorization_code"
+	nvGrantTypeRefresh = "refresh_token"
+
+	nvUserInfoURL = "https://openapi.naver.com/v1/nid/me"
+)
+
+// Naver struct
+// @doc: https://developers.naver.com/docs/login/api/api.md
+type Naver struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	HTTPRequest       *utils.HTTPClient
+}
+
+// nvRespErrorToken response of err
+type nvRespErrorToken struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// NvRespToken response of token
+type NvRespToken struct {
+	nvRespErrorToken
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn naver returns a string, e.g. "3600"
+	ExpiresIn string `json:"expires_in"`
+}
+
+// NvUserInfo user info
+type NvUserInfo struct {
+	ResultCode string `json:"resultcode"`
+	Message    string `json:"message"`
+	Response   struct {
+		ID           string `json:"id"`
+		Nickname     string `json:"nickname"`
+		Name         string `json:"name"`
+		Email        string `json:"email"`
+		Gender       string `json:"gender"`
+		Age          string `json:"age"`
+		Birthday     string `json:"birthday"`
+		Birthyear    string `json:"birthyear"`
+		ProfileImage string `json:"profile_image"`
+		Mobile       string `json:"mobile"`
+	} `json:"response"`
+}
+
+// GetAuthorizeURL get authorize url, args: state (required)
+func (n *Naver) GetAuthorizeURL(args ...string) string {
+
+	params := map[string]string{
+		"response_type": nvResponseType,
+		"client_id":     n.ClientID,
+		"redirect_uri":  n.RedirectURL,
+	}
+
+	if len(args) >= 1 {
+		params["state"] = args[0]
+	}
+
+	return fmt.Sprintf("%s?%s", nvAuthorizeURL, utils.QuerySortByKeyStr2(params))
+}
+
+// AuthorizeURL get authorize url, state is required, extra e.g. auth_type=reprompt
+func (n *Naver) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authState, authRedirectURL); err != nil {
+		return "", err
+	}
+	if err := checkRedirectURL(o.RedirectURL, n.RedirectURL, n.RedirectAllowlist); err != nil {
+		return "", err
+	}
+	if o.State == "" {
+		return "", errors.New("state is required")
+	}
+
+	params, err := o.apply(map[string]string{
+		"response_type": nvResponseType,
+		"client_id":     n.ClientID,
+		"redirect_uri":  o.redirectURL(n.RedirectURL),
+		"state":         o.State,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s?%s", nvAuthorizeURL, utils.QuerySortByKeyStr2(params)), nil
+}
+
+// Token naver requires the state of the callback, use TokenWithState
+func (n *Naver) Token(code string) (interface{}, error) {
+	return nil, errors.New("state is required, use TokenWithState")
+}
+
+// TokenWithState get token, state is the one returned to the callback
+func (n *Naver) TokenWithState(code, state string) (*NvRespToken, error) {
+	return n.doToken(nvTokenURL, code, state)
+}
+
+// doToken handle
+func (n *Naver) doToken(url, code, state string) (*NvRespToken, error) {
+
+	if state == "" {
+		return nil, errors.New("state is required")
+	}
+
+	params := map[string]string{
+		"grant_type":    nvGrantTypeAuth,
+		"client_id":     n.ClientID,
+		"client_secret": n.ClientSecret,
+		"code":          code,
+		"state":         state,
+	}
+
+	if err := n.HTTPRequest.HTTPGet(url, params, utils.NonIdempotent()); err != nil {
+		return nil, err
+	}
+
+	ret := new(NvRespToken)
+	if err := n.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// RefreshToken refresh token
+func (n *Naver) RefreshToken(refreshToken string) (interface{}, error) {
+	return n.doRefreshToken(nvTokenURL, refreshToken)
+}
+
+// doRefreshToken handle
+func (n *Naver) doRefreshToken(url, refreshToken string) (*NvRespToken, error) {
+
+	params := map[string]string{
+		"grant_type":    nvGrantTypeRefresh,
+		"client_id":     n.ClientID,
+		"client_secret": n.ClientSecret,
+		"refresh_token": refreshToken,
+	}
+
+	if err := n.HTTPRequest.HTTPGet(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(NvRespToken)
+	if err := n.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetMe get me
+func (n *Naver) GetMe(accessToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetUserInfo get user info, openID is ignored because the profile is resolved by access token
+func (n *Naver) GetUserInfo(accessToken, openID string) (interface{}, error) {
+	return n.doGetUserInfo(nvUserInfoURL, accessToken)
+}
+
+// doGetUserInfo handle
+func (n *Naver) doGetUserInfo(url, accessToken string) (*NvUserInfo, error) {
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	if err := n.HTTPRequest.HTTPGetWithHeader(url, nil, headers); err != nil {
+		return nil, err
+	}
+
+	ret := new(NvUserInfo)
+	if err := n.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
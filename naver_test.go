@@ -0,0 +1,158 @@
+package socialite
+
+import (
+	"github.com/birjemin/socialite/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	nvHTTPClient = &utils.HTTPClient{
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	nvObj = &Naver{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		RedirectURL:  "REDIRECT_URI",
+		HTTPRequest:  nvHTTPClient,
+	}
+)
+
+// TestNvGetAuthorizeURL test GetAuthorizeURL
+func TestNvGetAuthorizeURL(t *testing.T) {
+
+	url1 := "https://nid.naver.com/oauth2.0/authorize?client_id=CLIENT_ID&redirect_uri=REDIRECT_URI&response_type=code&state=STATE"
+
+	ast := assert.New(t)
+
+	ast.Equal(url1, nvObj.GetAuthorizeURL("STATE"))
+}
+
+// TestNvToken
+func TestNvToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"access_token":"YOUR_ACCESS_TOKEN","refresh_token":"YOUR_REFRESH_TOKEN","token_type":"bearer","expires_in":"3600"}`
+		for _, v := range []string{"grant_type", "client_id", "client_secret", "code", "state"} {
+			if r.FormValue(v) == "" {
+				ret = `{"error":"invalid_request","error_description":"no valid data in session"}`
+				break
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := nvObj.doToken(ts.URL, "code", "STATE")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("YOUR_ACCESS_TOKEN", ret.AccessToken)
+	ast.Equal("3600", ret.ExpiresIn)
+
+	// fail
+	ret, err = nvObj.doToken(ts.URL, "", "STATE")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("invalid_request", ret.Error)
+
+	_, err = nvObj.doToken(ts.URL, "code", "")
+	ast.EqualError(err, "state is required")
+
+	_, err = nvObj.Token("code")
+	ast.Error(err)
+}
+
+// TestNvRefreshToken
+func TestNvRefreshToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"access_token":"NEW_ACCESS_TOKEN","token_type":"bearer","expires_in":"3600"}`
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") == "" {
+			ret = `{"error":"invalid_request","error_description":"invalid refresh_token"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := nvObj.doRefreshToken(ts.URL, "YOUR_REFRESH_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("NEW_ACCESS_TOKEN", ret.AccessToken)
+
+	// fail
+	ret, err = nvObj.doRefreshToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("invalid_request", ret.Error)
+}
+
+// TestNvUserInfo
+func TestNvUserInfo(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"resultcode":"00","message":"success","response":{"id":"32742776","nickname":"OPENAPI","email":"openapi@naver.com","gender":"F","age":"40-49","birthday":"10-01","profile_image":"https://ssl.pstatic.net/static/pwe/address/nodata_33x33.gif"}}`
+		if r.Header.Get("Authorization") != "Bearer YOUR_ACCESS_TOKEN" {
+			ret = `{"resultcode":"024","message":"Authentication failed"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := nvObj.doGetUserInfo(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("00", ret.ResultCode)
+	ast.Equal("32742776", ret.Response.ID)
+	ast.Equal("openapi@naver.com", ret.Response.Email)
+
+	// fail
+	ret, err = nvObj.doGetUserInfo(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("024", ret.ResultCode)
+}
@@ -4,9 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/birjemin/socialite/utils"
-	jsoniter "github.com/json-iterator/go"
-	"regexp"
-	"strconv"
+	"strings"
 )
 
 const (
@@ -22,6 +20,18 @@ const (
 	qqMeURL = "https://graph.qq.com/oauth2.0/me"
 
 	qqUserInfoURL = "https://graph.qq.com/user/get_user_info"
+
+	// user info of mobile apps (android/ios sdk)
+	qqSimpleUserInfoURL = "https://openmobile.qq.com/user/get_simple_userinfo"
+
+	// ask for json instead of jsonp or form-encoded bodies
+	qqFmtJSON = "json"
+)
+
+// display of the authorize page
+const (
+	QqDisplayPC     = "pc"
+	QqDisplayMobile = "mobile"
 )
 
 // Qq struct
@@ -30,7 +40,13 @@ type Qq struct {
 	AppID       string
 	AppSecret   string
 	RedirectURL string
-	HTTPRequest *utils.HTTPClient
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	// RedirectStore keeps the per-request redirect url by state for TokenWithState
+	RedirectStore RedirectStore
+	// RequestUnionID request unionid on /oauth2.0/me, the app must be granted the unionid permission
+	RequestUnionID bool
+	HTTPRequest    *utils.HTTPClient
 }
 
 // qqRespErrorToken response of err
@@ -52,9 +68,22 @@ type QqRespMe struct {
 	qqRespErrorToken
 	ClientID string `json:"client_id"`
 	OpenID   string `json:"openid"`
+	UnionID  string `json:"unionid"`
+}
+
+// QqAPIError non-zero ret of the openapi (e.g. get_user_info)
+// @doc: https://wiki.connect.qq.com/%E5%85%AC%E5%85%B1%E8%BF%94%E5%9B%9E%E7%A0%81%E8%AF%B4%E6%98%8E
+type QqAPIError struct {
+	Ret int
+	Msg string
+}
+
+// Error error
+func (e *QqAPIError) Error() string {
+	return fmt.Sprintf("qq api error: %d %s", e.Ret, e.Msg)
 }
 
-// QqRespUserInfo user info
+// QqRespUserInfo user info of website apps
 type QqRespUserInfo struct {
 	Ret              int    `json:"ret"`
 	Msg              string `json:"msg"`
@@ -80,31 +109,172 @@ type QqRespUserInfo struct {
 	IsYellowVIPLevel string `json:"is_yellow_year_vip"`
 }
 
-// GetAuthorizeURL get authorize url
+// QqRespSimpleUserInfo user info of mobile apps, figureurl_qq (640x640) is not returned
+type QqRespSimpleUserInfo struct {
+	Ret              int    `json:"ret"`
+	Msg              string `json:"msg"`
+	Nickname         string `json:"nickname"`
+	Gender           string `json:"gender"`
+	FigureURL        string `json:"figureurl"`
+	FigureURL1       string `json:"figureurl_1"`
+	FigureURL2       string `json:"figureurl_2"`
+	FigureQqURL1     string `json:"figureurl_qq_1"`
+	FigureQqURL2     string `json:"figureurl_qq_2"`
+	IsYellowVIP      string `json:"is_yellow_vip"`
+	VIP              string `json:"vip"`
+	YellowVIPLevel   string `json:"yellow_vip_level"`
+	Level            string `json:"level"`
+	IsYellowVIPLevel string `json:"is_yellow_year_vip"`
+}
+
+// Avatar the largest avatar over https: figureurl_qq (640) > figureurl_qq_2 (100) > figureurl_2 (qzone 100)
+// > figureurl_1 (qzone 50) > figureurl_qq_1 (40) > figureurl (qzone 30)
+func (u *QqRespUserInfo) Avatar() string {
+	return qqLargestAvatar(u.FigureQqURL, u.FigureQqURL2, u.FigureURL2, u.FigureURL1, u.FigureQqURL1, u.FigureURL)
+}
+
+// Avatar the largest avatar over https, same order as QqRespUserInfo.Avatar
+func (u *QqRespSimpleUserInfo) Avatar() string {
+	return qqLargestAvatar(u.FigureQqURL2, u.FigureURL2, u.FigureURL1, u.FigureQqURL1, u.FigureURL)
+}
+
+// qqLargestAvatar the first non-empty url, the avatars are served over http but https works as well
+func qqLargestAvatar(urls ...string) string {
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+		if strings.HasPrefix(u, "http://") {
+			return "https://" + strings.TrimPrefix(u, "http://")
+		}
+		return u
+	}
+	return ""
+}
+
+// QqAuthorizeOptions options of the authorize url, empty ones are omitted
+type QqAuthorizeOptions struct {
+	// State required
+	State string
+	// Scope comma separated, e.g. get_user_info,list_album (default: get_user_info)
+	Scope string
+	// Display pc or mobile (default: pc)
+	Display string
+	// RedirectURL overrides Qq.RedirectURL
+	RedirectURL string
+}
+
+// validate validate the options
+func (o *QqAuthorizeOptions) validate() error {
+	if o.State == "" {
+		return errors.New("state is required")
+	}
+	switch o.Display {
+	case "", QqDisplayPC, QqDisplayMobile:
+	default:
+		return fmt.Errorf("display %q is invalid", o.Display)
+	}
+	return QqScopeCatalog.Validate(ParseScopes(o.Scope)...)
+}
+
+// GetAuthorizeURL get authorize url, args: state, scope, display
+// the args are passed through as is and the empty ones are omitted, use AuthorizeURLWithOptions for validation
 func (q *Qq) GetAuthorizeURL(args ...string) string {
 
-	params := make(map[string]string, 6)
-	params["response_type"] = authorizeResponseType
-	params["client_id"] = q.AppID
-	params["redirect_uri"] = q.RedirectURL
-
-	length := len(args)
-
-	if length < 1 {
-		panic("args is invalid, please input state, scope, display")
-	} else {
-		params["state"] = args[0]
-		if length >= 2 {
-			params["scope"] = args[1]
-			if length >= 3 {
-				params["display"] = args[2]
-			}
+	params := make(map[string]string, 3)
+	for k, name := range []string{"state", "scope", "display"} {
+		if k < len(args) {
+			params[name] = args[k]
+		}
+	}
+
+	return q.authorizeURL(q.RedirectURL, params)
+}
+
+// AuthorizeURLWithOptions get authorize url
+func (q *Qq) AuthorizeURLWithOptions(opts QqAuthorizeOptions) (string, error) {
+
+	ret, err := q.checkedAuthorizeURL(opts)
+	if err != nil {
+		return "", err
+	}
+	if err := bindRedirectURL(q.RedirectStore, opts.State, opts.RedirectURL, q.RedirectURL); err != nil {
+		return "", err
+	}
+	return ret, nil
+}
+
+// checkedAuthorizeURL validate the options and build the authorize url, the redirect url is not bound
+func (q *Qq) checkedAuthorizeURL(opts QqAuthorizeOptions) (string, error) {
+
+	if err := checkRedirectURL(opts.RedirectURL, q.RedirectURL, q.RedirectAllowlist); err != nil {
+		return "", err
+	}
+
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+
+	redirectURL := q.RedirectURL
+	if opts.RedirectURL != "" {
+		redirectURL = opts.RedirectURL
+	}
+
+	return q.authorizeURL(redirectURL, map[string]string{
+		"state":   opts.State,
+		"scope":   opts.Scope,
+		"display": opts.Display,
+	}), nil
+}
+
+// authorizeURL build the authorize url without validation, empty params are omitted
+func (q *Qq) authorizeURL(redirectURL string, optional map[string]string) string {
+
+	params := map[string]string{
+		"response_type": authorizeResponseType,
+		"client_id":     q.AppID,
+		"redirect_uri":  redirectURL,
+	}
+	for k, v := range optional {
+		if v != "" {
+			params[k] = v
 		}
 	}
 
 	return fmt.Sprintf("%s?%s", qqAuthorizeURL, utils.QuerySortByKeyStr2(params))
 }
 
+// AuthorizeURL get authorize url, state is required
+func (q *Qq) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authState, authScopes, authDisplay, authRedirectURL, authGranted); err != nil {
+		return "", err
+	}
+	scope, err := o.joinScopes(QqScopeCatalog)
+	if err != nil {
+		return "", err
+	}
+
+	opt := QqAuthorizeOptions{
+		State:       o.State,
+		Scope:       scope,
+		Display:     o.Display,
+		RedirectURL: o.RedirectURL,
+	}
+	ret, err := q.checkedAuthorizeURL(opt)
+	if err != nil {
+		return "", err
+	}
+	if ret, err = o.withExtra(ret); err != nil {
+		return "", err
+	}
+	if err := bindRedirectURL(q.RedirectStore, opt.State, opt.RedirectURL, q.RedirectURL); err != nil {
+		return "", err
+	}
+	return ret, nil
+}
+
 // Token get token
 func (q *Qq) Token(code string) (interface{}, error) {
 
@@ -117,16 +287,48 @@ func (q *Qq) Token(code string) (interface{}, error) {
 
 // doToken handle
 func (q *Qq) doToken(url, code string) (ret []byte, err error) {
+	return q.doTokenWithRedirectURL(url, code, q.RedirectURL)
+}
+
+// TokenWithRedirectURL get token with the redirect url of the authorize url,
+// it must be RedirectURL or allowed by RedirectAllowlist
+func (q *Qq) TokenWithRedirectURL(code, redirectURL string) (interface{}, error) {
+
+	if redirectURL == "" {
+		redirectURL = q.RedirectURL
+	}
+	if err := checkRedirectURL(redirectURL, q.RedirectURL, q.RedirectAllowlist); err != nil {
+		return nil, err
+	}
+	b, err := q.doTokenWithRedirectURL(qqTokenURL, code, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return q.getRespToken(b)
+}
+
+// TokenWithState get token with the redirect url bound to the state by the authorize url, see RedirectStore
+func (q *Qq) TokenWithState(code, state string) (interface{}, error) {
+	redirectURL, err := boundRedirectURL(q.RedirectStore, state, q.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return q.TokenWithRedirectURL(code, redirectURL)
+}
+
+// doTokenWithRedirectURL handle
+func (q *Qq) doTokenWithRedirectURL(url, code, redirectURL string) (ret []byte, err error) {
 
 	params := map[string]string{
 		"grant_type":    qqGrantTypeAuth,
 		"client_id":     q.AppID,
 		"client_secret": q.AppSecret,
 		"code":          code,
-		"redirect_uri":  q.RedirectURL,
+		"redirect_uri":  redirectURL,
+		"fmt":           qqFmtJSON,
 	}
 
-	if err := q.HTTPRequest.HTTPGet(url, params); err != nil {
+	if err := q.HTTPRequest.HTTPGet(url, params, utils.NonIdempotent()); err != nil {
 		return nil, err
 	}
 
@@ -155,6 +357,7 @@ func (q *Qq) doRefreshToken(url, refreshToken string) (ret []byte, err error) {
 		"client_id":     q.AppID,
 		"client_secret": q.AppSecret,
 		"refresh_token": refreshToken,
+		"fmt":           qqFmtJSON,
 	}
 
 	if err := q.HTTPRequest.HTTPGet(url, params); err != nil {
@@ -170,40 +373,7 @@ func (q *Qq) doRefreshToken(url, refreshToken string) (ret []byte, err error) {
 
 // getRespToken response
 func (q *Qq) getRespToken(b []byte) (*QqRespToken, error) {
-
-	match, _ := regexp.Match("error", b)
-
-	ret := new(QqRespToken)
-
-	// error
-	if match {
-		// regexp
-		pattern, err := regexp.Compile(`{.*}`)
-		if err != nil {
-			return ret, err
-		}
-
-		if err := jsoniter.Unmarshal(pattern.Find(b), &ret); err != nil {
-			return ret, err
-		}
-
-		return ret, errors.New("get token error")
-	}
-
-	pattern, err := regexp.Compile(`access_token=(\S*)&expires_in=(\S*)&refresh_token=(\S*)`)
-	if err != nil {
-		return ret, err
-	}
-	temp := pattern.FindSubmatch(b)
-
-	if len(temp) != 4 {
-		return ret, errors.New("length of result is invalid")
-	}
-	ret.AccessToken = string(temp[1])
-	expired, _ := strconv.ParseInt(string(temp[2]), 10, 64)
-	ret.ExpiresIn = int(expired)
-	ret.RefreshToken = string(temp[3])
-	return ret, nil
+	return qqParseToken(b)
 }
 
 // GetMe get me
@@ -221,6 +391,11 @@ func (q *Qq) doGetMe(url, accessToken string) (ret []byte, err error) {
 
 	params := map[string]string{
 		"access_token": accessToken,
+		"fmt":          qqFmtJSON,
+	}
+
+	if q.RequestUnionID {
+		params["unionid"] = "1"
 	}
 
 	if err := q.HTTPRequest.HTTPGet(url, params); err != nil {
@@ -236,38 +411,46 @@ func (q *Qq) doGetMe(url, accessToken string) (ret []byte, err error) {
 
 // getRespMe response
 func (q *Qq) getRespMe(b []byte) (*QqRespMe, error) {
+	return qqParseMe(b)
+}
 
-	match, _ := regexp.Match("error", b)
-	ret := new(QqRespMe)
+// GetUserInfo get user info of website apps, a non-zero ret is returned as *QqAPIError
+func (q *Qq) GetUserInfo(accessToken, openID string) (interface{}, error) {
+	return q.doGetUserInfo(qqUserInfoURL, accessToken, openID)
+}
 
-	// regexp
-	pattern, err := regexp.Compile(`{.*}`)
-	if err != nil {
-		return ret, err
-	}
+// doGetUserInfo handle
+func (q *Qq) doGetUserInfo(url, accessToken, openID string) (*QqRespUserInfo, error) {
 
-	// error
-	if match {
-		if err := jsoniter.Unmarshal(pattern.Find(b), &ret); err != nil {
-			return ret, err
-		}
+	params := map[string]string{
+		"access_token":       accessToken,
+		"oauth_consumer_key": q.AppID,
+		"openid":             openID,
+	}
 
-		return ret, errors.New("get token error")
+	if err := q.HTTPRequest.HTTPGet(url, params); err != nil {
+		return nil, err
 	}
 
-	if err := jsoniter.Unmarshal(pattern.Find(b), &ret); err != nil {
-		return ret, err
+	var ret = new(QqRespUserInfo)
+	if err := q.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
 	}
+	if ret.Ret != 0 {
+		return ret, &QqAPIError{Ret: ret.Ret, Msg: ret.Msg}
+	}
+
 	return ret, nil
 }
 
-// GetUserInfo get user info
-func (q *Qq) GetUserInfo(accessToken, openID string) (interface{}, error) {
-	return q.doGetUserInfo(qqUserInfoURL, accessToken, openID)
+// GetSimpleUserInfo get user info of mobile apps, the token is issued by the android/ios sdk
+// @doc: https://wiki.connect.qq.com/get_simple_userinfo
+func (q *Qq) GetSimpleUserInfo(accessToken, openID string) (*QqRespSimpleUserInfo, error) {
+	return q.doGetSimpleUserInfo(qqSimpleUserInfoURL, accessToken, openID)
 }
 
-// doGetUserInfo handle
-func (q *Qq) doGetUserInfo(url, accessToken, openID string) (*QqRespUserInfo, error) {
+// doGetSimpleUserInfo handle
+func (q *Qq) doGetSimpleUserInfo(url, accessToken, openID string) (*QqRespSimpleUserInfo, error) {
 
 	params := map[string]string{
 		"access_token":       accessToken,
@@ -279,10 +462,13 @@ func (q *Qq) doGetUserInfo(url, accessToken, openID string) (*QqRespUserInfo, er
 		return nil, err
 	}
 
-	var ret = new(QqRespUserInfo)
+	var ret = new(QqRespSimpleUserInfo)
 	if err := q.HTTPRequest.GetResponseJSON(ret); err != nil {
 		return nil, err
 	}
+	if ret.Ret != 0 {
+		return ret, &QqAPIError{Ret: ret.Ret, Msg: ret.Msg}
+	}
 
 	return ret, nil
 }
@@ -0,0 +1,86 @@
+package socialite
+
+import (
+	"github.com/birjemin/socialite/utils"
+	"time"
+)
+
+const (
+	qqCode2SessionURL = "https://api.q.qq.com/sns/jscode2session"
+)
+
+// QqMiniProgram server-side login of qq mini program, same protocol as the wechat mini program
+// @doc: https://q.qq.com/wiki/develop/miniprogram/server/open_port/port_login.html
+type QqMiniProgram struct {
+	AppID     string
+	AppSecret string
+	// WatermarkMaxAge max age of the watermark timestamp of decrypted data (default: not checked)
+	WatermarkMaxAge time.Duration
+	HTTPRequest     *utils.HTTPClient
+}
+
+// qqMiniRespError response of err
+type qqMiniRespError struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// QqRespSession response of code2Session
+type QqRespSession struct {
+	qqMiniRespError
+	OpenID     string `json:"openid"`
+	SessionKey string `json:"session_key"`
+	UnionID    string `json:"unionid"`
+}
+
+// QqMiniWatermark watermark of decrypted data
+type QqMiniWatermark = WxMiniWatermark
+
+// QqMiniPhoneNumber decrypted phone number
+type QqMiniPhoneNumber = WxMiniPhoneNumber
+
+// QqMiniUserInfo decrypted user info
+type QqMiniUserInfo = WxMiniUserInfo
+
+// Code2Session exchange the code of qq.login for openid, unionid and session_key
+func (q *QqMiniProgram) Code2Session(code string) (*QqRespSession, error) {
+	return q.doCode2Session(qqCode2SessionURL, code)
+}
+
+// doCode2Session handle
+func (q *QqMiniProgram) doCode2Session(url, code string) (*QqRespSession, error) {
+
+	ret := new(QqRespSession)
+	if err := miniProgramCode2Session(q.HTTPRequest, url, q.AppID, q.AppSecret, code, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// VerifyRawData check the signature (sha1 of rawData + session_key) of getUserInfo
+func (q *QqMiniProgram) VerifyRawData(rawData, signature, sessionKey string) bool {
+	return miniProgramVerifyRawData(rawData, signature, sessionKey)
+}
+
+// DecryptData decrypt encryptedData with iv and session_key into v, the watermark is checked
+func (q *QqMiniProgram) DecryptData(sessionKey, encryptedData, iv string, v interface{}) error {
+	return miniProgramDecrypt(q.AppID, q.WatermarkMaxAge, sessionKey, encryptedData, iv, v)
+}
+
+// DecryptPhoneNumber decrypt the data of getPhoneNumber
+func (q *QqMiniProgram) DecryptPhoneNumber(sessionKey, encryptedData, iv string) (*QqMiniPhoneNumber, error) {
+	ret := new(QqMiniPhoneNumber)
+	if err := q.DecryptData(sessionKey, encryptedData, iv, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// DecryptUserInfo decrypt the data of getUserInfo
+func (q *QqMiniProgram) DecryptUserInfo(sessionKey, encryptedData, iv string) (*QqMiniUserInfo, error) {
+	ret := new(QqMiniUserInfo)
+	if err := q.DecryptData(sessionKey, encryptedData, iv, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
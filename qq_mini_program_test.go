@@ -0,0 +1,84 @@
+package socialite
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var (
+	qqMiniObj = &QqMiniProgram{
+		AppID:       "1109999999",
+		AppSecret:   "SECRET",
+		HTTPRequest: httpClient,
+	}
+)
+
+// TestQqMiniCode2Session
+func TestQqMiniCode2Session(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"errcode":0,"errmsg":"","openid":"OPENID","session_key":"SESSIONKEY","unionid":"UNIONID"}`
+		if r.FormValue("appid") != "1109999999" || r.FormValue("js_code") == "" || r.FormValue("grant_type") != "authorization_code" {
+			ret = `{"errcode":-1,"errmsg":"invalid code"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := qqMiniObj.doCode2Session(ts.URL, "code")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(0, ret.ErrCode)
+	ast.Equal("OPENID", ret.OpenID)
+	ast.Equal("SESSIONKEY", ret.SessionKey)
+	ast.Equal("UNIONID", ret.UnionID)
+
+	// fail
+	ret, err = qqMiniObj.doCode2Session(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(-1, ret.ErrCode)
+}
+
+// TestQqMiniDecrypt
+func TestQqMiniDecrypt(t *testing.T) {
+
+	ast := assert.New(t)
+
+	// success
+	data := miniEncrypt(`{"openId":"OPENID","nickName":"NICKNAME","unionId":"UNIONID","watermark":{"appid":"1109999999","timestamp":1600000000}}`)
+	info, err := qqMiniObj.DecryptUserInfo(miniSessionKey, data, miniIV)
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("NICKNAME", info.NickName)
+	ast.Equal("UNIONID", info.UnionID)
+
+	rawData := `{"nickName":"NICKNAME"}`
+	sum := sha1.Sum([]byte(rawData + miniSessionKey))
+	ast.True(qqMiniObj.VerifyRawData(rawData, hex.EncodeToString(sum[:]), miniSessionKey))
+
+	// fail, the data of the wechat mini program
+	data = miniEncrypt(`{"phoneNumber":"13800138000","watermark":{"appid":"wx4f4bc4dec97d474b","timestamp":1600000000}}`)
+	_, err = qqMiniObj.DecryptPhoneNumber(miniSessionKey, data, miniIV)
+	ast.Error(err)
+	ast.False(qqMiniObj.VerifyRawData(rawData, "signature", miniSessionKey))
+}
@@ -0,0 +1,149 @@
+package socialite
+
+import (
+	"bytes"
+	"errors"
+	jsoniter "github.com/json-iterator/go"
+	"net/url"
+	"strconv"
+)
+
+// qqParseBody decode a body of the oauth2.0 apis into flat fields, the shapes QQ emits are
+// json: {"access_token":"...","expires_in":"7776000"}
+// jsonp: callback( {"client_id":"...","openid":"..."} );
+// form-encoded: access_token=...&expires_in=7776000&refresh_token=...
+// the fields may come in any order, nested json values are ignored
+func qqParseBody(b []byte) (map[string]string, error) {
+
+	body := bytes.TrimSpace(b)
+	if len(body) == 0 {
+		return nil, errors.New("response is empty")
+	}
+
+	// jsonp, strip the callback
+	if body[0] != '{' {
+		if start, end := bytes.IndexByte(body, '('), bytes.LastIndexByte(body, ')'); start >= 0 && end > start {
+			if isQqCallbackName(bytes.TrimSpace(body[:start])) {
+				body = bytes.TrimSpace(body[start+1 : end])
+			}
+		}
+	}
+
+	if len(body) > 0 && body[0] == '{' {
+		// numbers are kept as their text, expires_in and error may be numbers or strings
+		temp := make(map[string]jsoniter.RawMessage)
+		var json = jsoniter.ConfigCompatibleWithStandardLibrary
+		if err := json.Unmarshal(body, &temp); err != nil {
+			return nil, err
+		}
+		ret := make(map[string]string, len(temp))
+		for k, v := range temp {
+			switch jsoniter.Get(v).ValueType() {
+			case jsoniter.StringValue:
+				var val string
+				if err := json.Unmarshal(v, &val); err != nil {
+					return nil, err
+				}
+				ret[k] = val
+			case jsoniter.NumberValue, jsoniter.BoolValue:
+				ret[k] = string(v)
+			}
+		}
+		return ret, nil
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]string, len(values))
+	for k := range values {
+		ret[k] = values.Get(k)
+	}
+	return ret, nil
+}
+
+// isQqCallbackName the name of the jsonp callback, e.g. callback
+func isQqCallbackName(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if !(c == '_' || c == '$' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// qqParseError error fields of the body, error may be a number or a string
+func qqParseError(fields map[string]string) (qqRespErrorToken, error) {
+
+	ret := qqRespErrorToken{ErrMsg: fields["error_description"]}
+	code, ok := fields["error"]
+	if !ok || code == "" {
+		return ret, nil
+	}
+	errCode, err := strconv.Atoi(code)
+	if err != nil {
+		return ret, errors.New("error is invalid")
+	}
+	ret.ErrCode = errCode
+	return ret, nil
+}
+
+// qqParseToken parse the body of /oauth2.0/token
+func qqParseToken(b []byte) (*QqRespToken, error) {
+
+	ret := new(QqRespToken)
+
+	fields, err := qqParseBody(b)
+	if err != nil {
+		return ret, err
+	}
+
+	if ret.qqRespErrorToken, err = qqParseError(fields); err != nil {
+		return ret, err
+	}
+	if ret.ErrCode != 0 {
+		return ret, errors.New("get token error")
+	}
+
+	ret.AccessToken = fields["access_token"]
+	ret.RefreshToken = fields["refresh_token"]
+	if ret.AccessToken == "" {
+		return ret, errors.New("access_token is missing")
+	}
+	if expiresIn := fields["expires_in"]; expiresIn != "" {
+		if ret.ExpiresIn, err = strconv.Atoi(expiresIn); err != nil {
+			return ret, errors.New("expires_in is invalid")
+		}
+	}
+	return ret, nil
+}
+
+// qqParseMe parse the body of /oauth2.0/me
+func qqParseMe(b []byte) (*QqRespMe, error) {
+
+	ret := new(QqRespMe)
+
+	fields, err := qqParseBody(b)
+	if err != nil {
+		return ret, err
+	}
+
+	if ret.qqRespErrorToken, err = qqParseError(fields); err != nil {
+		return ret, err
+	}
+	if ret.ErrCode != 0 {
+		return ret, errors.New("get token error")
+	}
+
+	ret.ClientID = fields["client_id"]
+	ret.OpenID = fields["openid"]
+	ret.UnionID = fields["unionid"]
+	if ret.OpenID == "" {
+		return ret, errors.New("openid is missing")
+	}
+	return ret, nil
+}
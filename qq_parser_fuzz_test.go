@@ -0,0 +1,79 @@
+//go:build go1.18
+// +build go1.18
+
+package socialite
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"testing"
+	"unicode/utf8"
+)
+
+// FuzzQqParseBody the parser never panics on any body
+func FuzzQqParseBody(f *testing.F) {
+
+	seeds := []string{
+		`access_token=FE04&expires_in=7776000&refresh_token=88E4`,
+		`{"access_token":"FE04","expires_in":"7776000","refresh_token":"88E4"}`,
+		`callback( {"client_id":"YOUR_APPID","openid":"YOUR_OPENID"} );`,
+		`callback( {"error":100016,"error_description":"access token check failed"} );`,
+		`callback(`,
+		`)(`,
+		`{"error":[1,{"a":null}]}`,
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, _ = qqParseToken(b)
+		_, _ = qqParseMe(b)
+	})
+}
+
+// FuzzQqParseTokenRoundTrip every shape QQ emits parses back to the same token
+func FuzzQqParseTokenRoundTrip(f *testing.F) {
+
+	f.Add("FE04************************CCE2", 7776000, "88E4************************BE14")
+	f.Add("a&b=c", 0, "")
+	f.Add("callback( x );", -1, "{\"}")
+
+	f.Fuzz(func(t *testing.T, token string, expiresIn int, refresh string) {
+
+		if token == "" || !utf8.ValidString(token) || !utf8.ValidString(refresh) {
+			t.Skip()
+		}
+
+		form := url.Values{}
+		form.Set("refresh_token", refresh)
+		form.Set("expires_in", strconv.Itoa(expiresIn))
+		form.Set("access_token", token)
+
+		number, err := json.Marshal(map[string]interface{}{"access_token": token, "expires_in": expiresIn, "refresh_token": refresh})
+		if err != nil {
+			t.Fatal(err)
+		}
+		str, err := json.Marshal(map[string]interface{}{"refresh_token": refresh, "access_token": token, "expires_in": strconv.Itoa(expiresIn)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bodies := []string{
+			form.Encode(),
+			string(number),
+			string(str),
+			"callback( " + string(number) + " );",
+		}
+		for _, body := range bodies {
+			ret, err := qqParseToken([]byte(body))
+			if err != nil {
+				t.Fatalf("%s: %v", body, err)
+			}
+			if ret.AccessToken != token || ret.ExpiresIn != expiresIn || ret.RefreshToken != refresh {
+				t.Fatalf("%s: %#v", body, ret)
+			}
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package socialite
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestQqParseToken
+func TestQqParseToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	cases := []struct {
+		body      string
+		token     string
+		expiresIn int
+		refresh   string
+		errCode   int
+		err       bool
+	}{
+		{`access_token=FE04&expires_in=7776000&refresh_token=88E4`, "FE04", 7776000, "88E4", 0, false},
+		{`refresh_token=88E4&access_token=FE04&expires_in=7776000`, "FE04", 7776000, "88E4", 0, false},
+		{` access_token=FE04%3D&refresh_token=88E4&expires_in=7776000` + "\n", "FE04=", 7776000, "88E4", 0, false},
+		{`{"access_token":"FE04","expires_in":"7776000","refresh_token":"88E4"}`, "FE04", 7776000, "88E4", 0, false},
+		{`{"refresh_token":"88E4","expires_in":7776000,"access_token":"FE04"}`, "FE04", 7776000, "88E4", 0, false},
+		{`callback( {"access_token":"FE04","expires_in":7776000,"refresh_token":"88E4"} );`, "FE04", 7776000, "88E4", 0, false},
+		// the description contains "error", it is not an error
+		{`{"access_token":"error","expires_in":7776000,"refresh_token":"88E4"}`, "error", 7776000, "88E4", 0, false},
+		{`callback( {"error":100002,"error_description":"param client_secret is wrong or lost "} );`, "", 0, "", 100002, true},
+		{`{"error":"100019","error_description":"code to access token error"}`, "", 0, "", 100019, true},
+		{`error=100020&error_description=code+is+reused+error`, "", 0, "", 100020, true},
+		{`access_token=FE04&expires_in=forever`, "FE04", 0, "", 0, true},
+		{`{"error":"unknown"}`, "", 0, "", 0, true},
+		{`<html>500</html>`, "", 0, "", 0, true},
+		{`callback( {"access_token": );`, "", 0, "", 0, true},
+		{``, "", 0, "", 0, true},
+	}
+
+	for _, c := range cases {
+		ret, err := qqParseToken([]byte(c.body))
+		if c.err {
+			ast.Error(err, c.body)
+		} else {
+			ast.Nil(err, c.body)
+		}
+		if ret == nil {
+			ast.Fail("err result", c.body)
+			continue
+		}
+		ast.Equal(c.errCode, ret.ErrCode, c.body)
+		if !c.err {
+			ast.Equal(c.token, ret.AccessToken, c.body)
+			ast.Equal(c.expiresIn, ret.ExpiresIn, c.body)
+			ast.Equal(c.refresh, ret.RefreshToken, c.body)
+		}
+	}
+}
+
+// TestQqParseMe
+func TestQqParseMe(t *testing.T) {
+
+	ast := assert.New(t)
+
+	cases := []struct {
+		body    string
+		openID  string
+		unionID string
+		errCode int
+		err     bool
+	}{
+		{`callback( {"client_id":"YOUR_APPID","openid":"YOUR_OPENID"} ); `, "YOUR_OPENID", "", 0, false},
+		{`callback({"openid":"YOUR_OPENID","unionid":"YOUR_UNIONID","client_id":"YOUR_APPID"});`, "YOUR_OPENID", "YOUR_UNIONID", 0, false},
+		{`{"client_id":"YOUR_APPID","openid":"YOUR_OPENID","unionid":"YOUR_UNIONID"}`, "YOUR_OPENID", "YOUR_UNIONID", 0, false},
+		{`callback( {"error":100016,"error_description":"access token check failed"} );`, "", "", 100016, true},
+		{`{"client_id":"YOUR_APPID"}`, "", "", 0, true},
+		{`callback( {"openid":"YOUR_OPENID"}`, "", "", 0, true},
+	}
+
+	for _, c := range cases {
+		ret, err := qqParseMe([]byte(c.body))
+		if c.err {
+			ast.Error(err, c.body)
+		} else {
+			ast.Nil(err, c.body)
+		}
+		ast.Equal(c.errCode, ret.ErrCode, c.body)
+		ast.Equal(c.openID, ret.OpenID, c.body)
+		ast.Equal(c.unionID, ret.UnionID, c.body)
+	}
+}
@@ -1,6 +1,7 @@
 package socialite
 
 import (
+	"errors"
 	"github.com/birjemin/socialite/utils"
 	"github.com/stretchr/testify/assert"
 	"net/http"
@@ -39,6 +40,36 @@ func TestGetAuthorizeURL(t *testing.T) {
 	ast.Equal(url3, qqObj.GetAuthorizeURL("rand_str", "get_user_info", "pc", "extra"))
 }
 
+// TestQqAuthorizeURLWithOptions
+func TestQqAuthorizeURLWithOptions(t *testing.T) {
+
+	url1 := "https://graph.qq.com/oauth2.0/authorize?client_id=test_app_id&display=mobile&redirect_uri=http%3A%2F%2Flocalhost%2Fredirect_uri&response_type=code&state=rand_str"
+
+	ast := assert.New(t)
+
+	// success
+	ret, err := qqObj.AuthorizeURLWithOptions(QqAuthorizeOptions{State: "rand_str", Display: QqDisplayMobile})
+	ast.Nil(err)
+	ast.Equal(url1, ret)
+	ast.Equal(url1, qqObj.GetAuthorizeURL("rand_str", "", "mobile"))
+
+	// the apis granted to the app are allowed
+	ret, err = qqObj.AuthorizeURLWithOptions(QqAuthorizeOptions{State: "rand_str", Scope: "get_user_info,get_info,add_share"})
+	ast.Nil(err)
+	ast.Equal("https://graph.qq.com/oauth2.0/authorize?client_id=test_app_id&redirect_uri=http%3A%2F%2Flocalhost%2Fredirect_uri&response_type=code&scope=get_user_info%2Cget_info%2Cadd_share&state=rand_str", ret)
+
+	// fail
+	_, err = qqObj.AuthorizeURLWithOptions(QqAuthorizeOptions{Display: QqDisplayMobile})
+	ast.EqualError(err, "state is required")
+	_, err = qqObj.AuthorizeURLWithOptions(QqAuthorizeOptions{State: "rand_str", Display: "wap"})
+
+	ast.Error(err)
+
+	// the positional args are passed through without validation
+	ast.Equal("https://graph.qq.com/oauth2.0/authorize?client_id=test_app_id&redirect_uri=http%3A%2F%2Flocalhost%2Fredirect_uri&response_type=code", qqObj.GetAuthorizeURL())
+	ast.Equal("https://graph.qq.com/oauth2.0/authorize?client_id=test_app_id&display=wap&redirect_uri=http%3A%2F%2Flocalhost%2Fredirect_uri&response_type=code&scope=get_user_info%2Cget_info&state=rand_str", qqObj.GetAuthorizeURL("rand_str", "get_user_info,get_info", "wap"))
+}
+
 // TestGetErrRespToken
 func TestGetErrRespToken(t *testing.T) {
 
@@ -278,6 +309,102 @@ func TestQqMe(t *testing.T) {
 	ast.Equal(100016, ret.ErrCode)
 }
 
+// TestGetJSONRespToken
+func TestGetJSONRespToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	// expires_in is a string
+	ret, err := qqObj.getRespToken([]byte(`{"access_token":"FE04************************CCE2","expires_in":"7776000","refresh_token":"88E4************************BE14"}`))
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("FE04************************CCE2", ret.AccessToken)
+	ast.Equal(7776000, ret.ExpiresIn)
+	ast.Equal("88E4************************BE14", ret.RefreshToken)
+
+	// expires_in is a number
+	ret, err = qqObj.getRespToken([]byte(` {"access_token":"FE04","expires_in":7776000,"refresh_token":"88E4"}`))
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(7776000, ret.ExpiresIn)
+
+	// fail
+	ret, err = qqObj.getRespToken([]byte(`{"error":100002,"error_description":"param client_secret is wrong or lost "}`))
+	ast.Error(err)
+	ast.Equal(100002, ret.ErrCode)
+}
+
+// TestQqMeJSON
+func TestQqMeJSON(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"client_id":"YOUR_APPID","openid":"YOUR_OPENID"}`
+		if r.FormValue("fmt") != "json" {
+			ret = `callback( {"client_id":"YOUR_APPID","openid":"YOUR_OPENID"} );`
+		}
+		if r.FormValue("unionid") == "1" {
+			ret = `{"client_id":"YOUR_APPID","openid":"YOUR_OPENID","unionid":"YOUR_UNIONID"}`
+		}
+		if r.FormValue("access_token") == "" {
+			ret = `{"error":100016,"error_description":"access token check failed"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	b, err := qqObj.doGetMe(ts.URL, "ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ret, err := qqObj.getRespMe(b)
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("YOUR_OPENID", ret.OpenID)
+	ast.Equal("", ret.UnionID)
+
+	// unionid
+	obj := *qqObj
+	obj.RequestUnionID = true
+	b, err = obj.doGetMe(ts.URL, "ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ret, err = obj.getRespMe(b)
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("YOUR_OPENID", ret.OpenID)
+	ast.Equal("YOUR_UNIONID", ret.UnionID)
+
+	// fail
+	b, err = qqObj.doGetMe(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ret, err = qqObj.getRespMe(b)
+	ast.Error(err)
+	ast.Equal(100016, ret.ErrCode)
+}
+
 // TestQqUserInfo
 func TestQqUserInfo(t *testing.T) {
 
@@ -310,10 +437,76 @@ func TestQqUserInfo(t *testing.T) {
 
 	// fail
 	ret, err = qqObj.doGetUserInfo(ts.URL, "", "")
+	if ret == nil {
+		ast.Fail("err result")
+		return
+	}
+	ast.Equal(1001, ret.Ret)
+
+	var apiErr *QqAPIError
+	if ast.True(errors.As(err, &apiErr)) {
+		ast.Equal(1001, apiErr.Ret)
+		ast.Equal("invalid openid", apiErr.Msg)
+	}
+}
+
+// TestQqSimpleUserInfo
+func TestQqSimpleUserInfo(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"ret":0,"msg":"","nickname":"YOUR_NICK_NAME","gender":"男","figureurl":"http://qzapp.qlogo.cn/qzapp/APPID/OPENID/30","figureurl_1":"http://qzapp.qlogo.cn/qzapp/APPID/OPENID/50","figureurl_2":"http://qzapp.qlogo.cn/qzapp/APPID/OPENID/100","figureurl_qq_1":"http://q.qlogo.cn/qqapp/APPID/OPENID/40","figureurl_qq_2":""}`
+		if r.FormValue("access_token") == "" || r.FormValue("oauth_consumer_key") != "test_app_id" {
+			ret = `{"ret":100030,"msg":"this api without user authorization"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := qqObj.doGetSimpleUserInfo(ts.URL, "YOUR_ACCESS_TOKEN", "YOUR_OPENID")
 	if err != nil {
 		ast.Fail(err.Error())
 		return
 	}
+	ast.Equal("YOUR_NICK_NAME", ret.Nickname)
+	// figureurl_qq_2 is empty
+	ast.Equal("https://qzapp.qlogo.cn/qzapp/APPID/OPENID/100", ret.Avatar())
 
-	ast.Equal(1001, ret.Ret)
+	// fail
+	_, err = qqObj.doGetSimpleUserInfo(ts.URL, "", "YOUR_OPENID")
+	var apiErr *QqAPIError
+	if ast.True(errors.As(err, &apiErr)) {
+		ast.Equal(100030, apiErr.Ret)
+	}
+}
+
+// TestQqAvatar
+func TestQqAvatar(t *testing.T) {
+
+	ast := assert.New(t)
+
+	info := &QqRespUserInfo{
+		FigureURL:    "http://qzapp.qlogo.cn/qzapp/APPID/OPENID/30",
+		FigureQqURL1: "http://thirdqq.qlogo.cn/g?b=oidb&k=K&s=40",
+		FigureQqURL2: "http://thirdqq.qlogo.cn/g?b=oidb&k=K&s=100",
+		FigureQqURL:  "http://thirdqq.qlogo.cn/g?b=oidb&k=K&s=640",
+	}
+	ast.Equal("https://thirdqq.qlogo.cn/g?b=oidb&k=K&s=640", info.Avatar())
+
+	info.FigureQqURL = ""
+	ast.Equal("https://thirdqq.qlogo.cn/g?b=oidb&k=K&s=100", info.Avatar())
+
+	info.FigureQqURL2 = ""
+	info.FigureQqURL1 = "https://thirdqq.qlogo.cn/g?b=oidb&k=K&s=40"
+	ast.Equal("https://thirdqq.qlogo.cn/g?b=oidb&k=K&s=40", info.Avatar())
+
+	ast.Equal("", new(QqRespUserInfo).Avatar())
 }
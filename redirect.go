@@ -0,0 +1,160 @@
+package socialite
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// RedirectAllowlist allowed per-request redirect urls, an entry is one of
+// exact: https://domain.com/callback
+// host wildcard: https://*.domain.com/callback, any subdomain but not domain.com itself
+// path prefix: https://domain.com/auth/*, any path under /auth/ and any query
+// scheme and port must always match, userinfo, fragments and dot segments are rejected
+type RedirectAllowlist []string
+
+// Allow whether the redirect url is allowed
+func (l RedirectAllowlist) Allow(redirectURL string) bool {
+	return l.Validate(redirectURL) == nil
+}
+
+// Validate error when the redirect url is not allowed
+func (l RedirectAllowlist) Validate(redirectURL string) error {
+
+	u, err := parseRedirectURL(redirectURL)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range l {
+		pattern, err := url.Parse(entry)
+		if err != nil {
+			continue
+		}
+		if matchRedirectURL(pattern, u) {
+			return nil
+		}
+	}
+	return errors.New("redirect url is not allowed")
+}
+
+// parseRedirectURL parse and reject the urls which are ambiguous or can not be a callback
+func parseRedirectURL(redirectURL string) (*url.URL, error) {
+
+	if strings.ContainsAny(redirectURL, "\\\r\n\t") {
+		return nil, errors.New("redirect url is invalid")
+	}
+
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		return nil, errors.New("redirect url is invalid")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("redirect url must be an absolute http(s) url")
+	}
+	if u.Host == "" || u.User != nil || u.Opaque != "" {
+		return nil, errors.New("redirect url is invalid")
+	}
+	if u.Fragment != "" || strings.Contains(redirectURL, "#") {
+		return nil, errors.New("redirect url must not contain a fragment")
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == "." || segment == ".." {
+			return nil, errors.New("redirect url must not contain dot segments")
+		}
+	}
+	return u, nil
+}
+
+// matchRedirectURL whether the url matches the entry of the allowlist
+func matchRedirectURL(pattern, u *url.URL) bool {
+
+	if !strings.EqualFold(pattern.Scheme, u.Scheme) || pattern.Port() != u.Port() {
+		return false
+	}
+
+	host, hostname := strings.ToLower(pattern.Hostname()), strings.ToLower(u.Hostname())
+	if strings.HasPrefix(host, "*.") {
+		if !strings.HasSuffix(hostname, host[1:]) || len(hostname) <= len(host)-1 {
+			return false
+		}
+	} else if host != hostname {
+		return false
+	}
+
+	if strings.HasSuffix(pattern.Path, "*") {
+		return strings.HasPrefix(u.Path, strings.TrimSuffix(pattern.Path, "*"))
+	}
+	return pattern.Path == u.Path && pattern.RawQuery == u.RawQuery
+}
+
+// checkRedirectURL the configured redirect url is always allowed, others must be in the allowlist
+func checkRedirectURL(redirectURL, defaultURL string, allowlist RedirectAllowlist) error {
+	if redirectURL == "" || redirectURL == defaultURL {
+		return nil
+	}
+	return allowlist.Validate(redirectURL)
+}
+
+// RedirectStore keeps the redirect url of the authorize url by state, so that the token exchange
+// sends back the exact same value, implement it with a shared storage (e.g. redis or the session)
+// when running multiple instances
+type RedirectStore interface {
+	// Set keep the redirect url of the state
+	Set(state, redirectURL string) error
+	// Take get and remove the redirect url of the state, "" when it is not found
+	Take(state string) (string, error)
+}
+
+// MemoryRedirectStore process-local RedirectStore, only suitable for a single instance
+type MemoryRedirectStore struct {
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+// Set
+func (s *MemoryRedirectStore) Set(state, redirectURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.urls == nil {
+		s.urls = make(map[string]string)
+	}
+	s.urls[state] = redirectURL
+	return nil
+}
+
+// Take
+func (s *MemoryRedirectStore) Take(state string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	redirectURL := s.urls[state]
+	delete(s.urls, state)
+	return redirectURL, nil
+}
+
+// bindRedirectURL keep the per-request redirect url by state, the configured one is not kept
+func bindRedirectURL(store RedirectStore, state, redirectURL, defaultURL string) error {
+	if store == nil || redirectURL == "" || redirectURL == defaultURL {
+		return nil
+	}
+	if state == "" {
+		return errors.New("state is required to bind the redirect url")
+	}
+	return store.Set(state, redirectURL)
+}
+
+// boundRedirectURL the redirect url kept by state, the configured one when none is kept
+func boundRedirectURL(store RedirectStore, state, defaultURL string) (string, error) {
+	if store == nil {
+		return "", errors.New("redirect store is not set")
+	}
+	redirectURL, err := store.Take(state)
+	if err != nil {
+		return "", err
+	}
+	if redirectURL == "" {
+		return defaultURL, nil
+	}
+	return redirectURL, nil
+}
@@ -0,0 +1,171 @@
+package socialite
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRedirectAllowlist
+func TestRedirectAllowlist(t *testing.T) {
+
+	ast := assert.New(t)
+
+	allowlist := RedirectAllowlist{
+		"https://domain.com/callback",
+		"https://*.domain.com/callback",
+		"https://auth.domain.com/env/*",
+		"http://localhost:8080/callback",
+	}
+
+	allowed := []string{
+		"https://domain.com/callback",
+		"https://DOMAIN.com/callback",
+		"https://a.domain.com/callback",
+		"https://a.b.domain.com/callback",
+		"https://auth.domain.com/env/staging/callback",
+		"https://auth.domain.com/env/staging/callback?from=web",
+		"http://localhost:8080/callback",
+	}
+	for _, v := range allowed {
+		ast.True(allowlist.Allow(v), v)
+	}
+
+	denied := []string{
+		// scheme, port, path and query must match
+		"http://domain.com/callback",
+		"https://domain.com:8443/callback",
+		"http://localhost/callback",
+		"https://domain.com/callback/other",
+		"https://domain.com/callback?next=https://evil.com",
+		// host wildcard
+		"https://evildomain.com/callback",
+		"https://domain.com.evil.com/callback",
+		"https://.domain.com/callback",
+		// open redirect attempts
+		"https://domain.com@evil.com/callback",
+		"https://evil.com\\@domain.com/callback",
+		"https://evil.com#@domain.com/callback",
+		"https://domain.com/callback#fragment",
+		"https://auth.domain.com/env/../admin",
+		"https://auth.domain.com/env/%2e%2e/admin",
+		"//domain.com/callback",
+		"/callback",
+		"javascript:alert(1)",
+		"",
+	}
+	for _, v := range denied {
+		ast.False(allowlist.Allow(v), v)
+	}
+
+	ast.False(RedirectAllowlist(nil).Allow("https://domain.com/callback"))
+}
+
+// TestRedirectStore
+func TestRedirectStore(t *testing.T) {
+
+	ast := assert.New(t)
+
+	obj := *lnObj
+	obj.RedirectAllowlist = RedirectAllowlist{"https://auth.domain.com/env/*"}
+	obj.RedirectStore = &MemoryRedirectStore{}
+
+	// success, the exact redirect url of the authorize url is kept by state
+	redirectURL := "https://auth.domain.com/env/staging/callback?z=1&a=%7E"
+	_, err := obj.AuthorizeURL(WithState("STATE"), WithRedirectURL(redirectURL))
+	ast.Nil(err)
+
+	ret, err := boundRedirectURL(obj.RedirectStore, "STATE", obj.RedirectURL)
+	ast.Nil(err)
+	ast.Equal(redirectURL, ret)
+
+	// taken once
+	ret, err = boundRedirectURL(obj.RedirectStore, "STATE", obj.RedirectURL)
+	ast.Nil(err)
+	ast.Equal(obj.RedirectURL, ret)
+
+	// the configured one is not kept
+	_, err = obj.AuthorizeURL(WithState("OTHER"))
+	ast.Nil(err)
+	ret, _ = boundRedirectURL(obj.RedirectStore, "OTHER", obj.RedirectURL)
+	ast.Equal(obj.RedirectURL, ret)
+
+	// fail
+	_, err = obj.AuthorizeURL(WithRedirectURL(redirectURL))
+	ast.EqualError(err, "state is required to bind the redirect url")
+
+	// nothing is kept when the url can not be built
+	for _, s := range []ISocialite{&Qq{AppID: "APPID", RedirectURL: "https://domain.com/callback"}, &Weibo{ClientID: "CLIENT_ID", RedirectURL: "https://domain.com/callback"}} {
+		store := &MemoryRedirectStore{}
+		opts := []AuthOption{WithState("STATE"), WithDisplay("mobile"), WithRedirectURL("https://a.domain.com/callback"), WithExtra("display", "pc")}
+		switch v := s.(type) {
+		case *Qq:
+			v.RedirectAllowlist, v.RedirectStore = RedirectAllowlist{"https://*.domain.com/callback"}, store
+		case *Weibo:
+			v.RedirectAllowlist, v.RedirectStore = RedirectAllowlist{"https://*.domain.com/callback"}, store
+		}
+		_, err = s.AuthorizeURL(opts...)
+		ast.EqualError(err, `extra param "display" is reserved`)
+		ret, _ = boundRedirectURL(store, "STATE", "")
+		ast.Equal("", ret)
+	}
+
+	for _, s := range []interface {
+		TokenWithState(code, state string) (interface{}, error)
+	}{qqObj, wbObj, msObj, fbObj, lnObj, kkObj} {
+		_, err = s.TokenWithState("code", "STATE")
+		ast.EqualError(err, "redirect store is not set")
+	}
+}
+
+// TestTokenWithRedirectURL
+func TestTokenWithRedirectURL(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"access_token":"FE04","expires_in":"7776000","refresh_token":"88E4"}`
+		if r.FormValue("redirect_uri") != "https://a.domain.com/qq/callback" {
+			ret = `{"error":100010,"error_description":"redirect uri is illegal"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	obj := *qqObj
+	obj.RedirectAllowlist = RedirectAllowlist{"https://*.domain.com/qq/callback"}
+
+	// success, the redirect url of the authorize url is sent
+	b, err := obj.doTokenWithRedirectURL(ts.URL, "code", "https://a.domain.com/qq/callback")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ret, err := obj.getRespToken(b)
+	ast.Nil(err)
+	ast.Equal("FE04", ret.AccessToken)
+
+	// fail, the default one
+	b, err = obj.doToken(ts.URL, "code")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	_, err = obj.getRespToken(b)
+	ast.Error(err)
+
+	// not allowed, no request is sent
+	for _, s := range []interface {
+		TokenWithRedirectURL(code, redirectURL string) (interface{}, error)
+	}{&obj, qqObj, wbObj, msObj, fbObj, lnObj, kkObj} {
+		_, err = s.TokenWithRedirectURL("code", "https://evil.com/callback")
+		ast.EqualError(err, "redirect url is not allowed")
+	}
+}
@@ -0,0 +1,204 @@
+package socialite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope permission requested on the authorize url
+type Scope string
+
+// ScopeCatalog known scopes of a provider and the separator of the scope param
+type ScopeCatalog struct {
+	Separator string
+	Scopes    []Scope
+	// Open unknown scopes are allowed, e.g. the permissions of microsoft graph
+	Open bool
+	// Incremental the provider adds the requested scopes to the existing grant, otherwise the new
+	// token only has the requested scopes and the granted ones must be requested again
+	Incremental bool
+	// All the scope which covers all the others, e.g. all of qq and weibo
+	All Scope
+}
+
+// scopes of qq
+// @doc: https://wiki.connect.qq.com/api%E5%88%97%E8%A1%A8
+const (
+	QqScopeAll            = "all"
+	QqScopeGetUserInfo    = "get_user_info"
+	QqScopeListAlbum      = "list_album"
+	QqScopeUploadPic      = "upload_pic"
+	QqScopeAddAlbum       = "add_album"
+	QqScopeGetVipInfo     = "get_vip_info"
+	QqScopeGetVipRichInfo = "get_vip_rich_info"
+)
+
+// scopes of weibo
+// @doc: https://open.weibo.com/wiki/Scope
+const (
+	WbScopeAll                        = "all"
+	WbScopeEmail                      = "email"
+	WbScopeDirectMessagesWrite        = "direct_messages_write"
+	WbScopeDirectMessagesRead         = "direct_messages_read"
+	WbScopeInvitationWrite            = "invitation_write"
+	WbScopeFriendshipsGroupsRead      = "friendships_groups_read"
+	WbScopeFriendshipsGroupsWrite     = "friendships_groups_write"
+	WbScopeStatusesToMeRead           = "statuses_to_me_read"
+	WbScopeFollowAppOfficialMicroblog = "follow_app_official_microblog"
+)
+
+// scopes of wechat website
+const (
+	WxScopeLogin = wxScope
+)
+
+// scope catalogs of the providers
+var (
+	// qq connect grants the apis per app (get_info, add_share, add_topic, ...), unknown scopes are allowed
+	QqScopeCatalog = &ScopeCatalog{
+		Separator: ",",
+		Scopes: []Scope{QqScopeAll, QqScopeGetUserInfo, QqScopeListAlbum, QqScopeUploadPic, QqScopeAddAlbum,
+			QqScopeGetVipInfo, QqScopeGetVipRichInfo},
+		Open: true,
+		All:  QqScopeAll,
+	}
+
+	WeiboScopeCatalog = &ScopeCatalog{
+		Separator: ",",
+		Scopes: []Scope{WbScopeAll, WbScopeEmail, WbScopeDirectMessagesWrite, WbScopeDirectMessagesRead,
+			WbScopeInvitationWrite, WbScopeFriendshipsGroupsRead, WbScopeFriendshipsGroupsWrite,
+			WbScopeStatusesToMeRead, WbScopeFollowAppOfficialMicroblog},
+		All: WbScopeAll,
+	}
+
+	WechatScopeCatalog = &ScopeCatalog{
+		Separator:   ",",
+		Scopes:      []Scope{WxScopeLogin},
+		Incremental: true,
+	}
+
+	WechatOfficialAccountScopeCatalog = &ScopeCatalog{
+		Separator:   ",",
+		Scopes:      []Scope{WxScopeBase, WxScopeUserInfo},
+		Incremental: true,
+	}
+
+	FacebookScopeCatalog = &ScopeCatalog{
+		Separator:   ",",
+		Open:        true,
+		Incremental: true,
+	}
+
+	LineScopeCatalog = &ScopeCatalog{
+		Separator: " ",
+		Scopes:    []Scope{"profile", "openid", "email"},
+	}
+
+	KakaoScopeCatalog = &ScopeCatalog{
+		Separator:   ",",
+		Open:        true,
+		Incremental: true,
+	}
+
+	MicrosoftScopeCatalog = &ScopeCatalog{
+		Separator:   " ",
+		Open:        true,
+		Incremental: true,
+	}
+)
+
+// Contains whether the scope is in the catalog
+func (c *ScopeCatalog) Contains(scope Scope) bool {
+	return hasScope(c.Scopes, scope)
+}
+
+// Validate error for empty scopes, separators inside a scope and unknown scopes of a closed catalog
+func (c *ScopeCatalog) Validate(scopes ...Scope) error {
+	for _, scope := range scopes {
+		if scope == "" || strings.ContainsAny(string(scope), ", ") {
+			return fmt.Errorf("scope %q is invalid", scope)
+		}
+		if !c.Open && !c.Contains(scope) {
+			return fmt.Errorf("scope %q is unknown", scope)
+		}
+	}
+	return nil
+}
+
+// Join join the scopes with the separator of the provider, duplicates are dropped
+func (c *ScopeCatalog) Join(scopes ...Scope) string {
+	ret := make([]string, 0, len(scopes))
+	for _, scope := range uniqueScopes(scopes) {
+		ret = append(ret, string(scope))
+	}
+	return strings.Join(ret, c.Separator)
+}
+
+// ParseScopes parse the scopes of a token response, separated by commas and/or spaces
+func ParseScopes(s string) []Scope {
+	var ret []Scope
+	for _, v := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		ret = append(ret, Scope(v))
+	}
+	return ret
+}
+
+// ToScopes convert strings to scopes
+func ToScopes(scopes ...string) []Scope {
+	ret := make([]Scope, 0, len(scopes))
+	for _, v := range scopes {
+		ret = append(ret, Scope(v))
+	}
+	return ret
+}
+
+// DeclinedScopes the requested scopes which are not granted, e.g. the user unchecked an optional one
+func DeclinedScopes(requested, granted []Scope) []Scope {
+	return subtractScopes(requested, granted)
+}
+
+// MergeScopes merge the scopes of a new token into the granted ones of the stored token,
+// providers like microsoft and facebook only return the scopes of the step-up consent
+func MergeScopes(granted []Scope, scopes ...Scope) []Scope {
+	return uniqueScopes(append(append([]Scope(nil), granted...), scopes...))
+}
+
+// Covers whether the granted scopes cover all the scopes
+func (c *ScopeCatalog) Covers(granted []Scope, scopes ...Scope) bool {
+	if c.All != "" && hasScope(granted, c.All) {
+		return true
+	}
+	return len(subtractScopes(scopes, granted)) == 0
+}
+
+// subtractScopes the scopes which are not in the others, duplicates are dropped
+func subtractScopes(scopes, others []Scope) []Scope {
+	var ret []Scope
+	for _, scope := range uniqueScopes(scopes) {
+		if !hasScope(others, scope) {
+			ret = append(ret, scope)
+		}
+	}
+	return ret
+}
+
+// hasScope whether the scope is in scopes
+func hasScope(scopes []Scope, scope Scope) bool {
+	for _, v := range scopes {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueScopes drop the duplicates, the order is kept
+func uniqueScopes(scopes []Scope) []Scope {
+	var ret []Scope
+	for _, scope := range scopes {
+		if !hasScope(ret, scope) {
+			ret = append(ret, scope)
+		}
+	}
+	return ret
+}
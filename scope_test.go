@@ -0,0 +1,51 @@
+package socialite
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestScopeCatalog
+func TestScopeCatalog(t *testing.T) {
+
+	ast := assert.New(t)
+
+	// success
+	ast.Nil(QqScopeCatalog.Validate(QqScopeGetUserInfo, QqScopeListAlbum))
+	ast.Nil(QqScopeCatalog.Validate("get_info", "add_share", "add_topic", "get_other_info"))
+	ast.Nil(WeiboScopeCatalog.Validate(WbScopeEmail, WbScopeFollowAppOfficialMicroblog))
+	ast.Nil(MicrosoftScopeCatalog.Validate("User.Read", "Mail.Read"))
+	ast.Equal("get_user_info,list_album", QqScopeCatalog.Join(QqScopeGetUserInfo, QqScopeListAlbum, QqScopeGetUserInfo))
+	ast.Equal("profile openid", LineScopeCatalog.Join("profile", "openid"))
+
+	// fail
+	ast.EqualError(WeiboScopeCatalog.Validate("get_info"), `scope "get_info" is unknown`)
+	ast.EqualError(WeiboScopeCatalog.Validate("email,all"), `scope "email,all" is invalid`)
+	ast.Error(MicrosoftScopeCatalog.Validate(""))
+	ast.Error(WechatScopeCatalog.Validate(WxScopeUserInfo))
+}
+
+// TestGrantedScopes
+func TestGrantedScopes(t *testing.T) {
+
+	ast := assert.New(t)
+
+	ast.Equal([]Scope{"openid", "profile", "User.Read"}, ParseScopes("openid profile  User.Read"))
+	ast.Equal([]Scope{"email", "all"}, ParseScopes("email, all"))
+	ast.Nil(ParseScopes(""))
+
+	ast.Equal([]Scope{"profile", "openid"}, (&LnRespToken{Scope: "profile openid"}).Scopes())
+	ast.Equal([]Scope{"account_email", "profile_nickname"}, (&KkRespToken{Scope: "account_email profile_nickname"}).Scopes())
+	ast.Equal([]Scope{"openid", "User.Read"}, (&MsRespToken{Scope: "openid User.Read"}).Scopes())
+	ast.Equal([]Scope{WbScopeEmail}, (&WbTokenInfo{Scope: "email"}).Scopes())
+	ast.Equal([]Scope{"public_profile"}, (&FbDebugTokenData{Scopes: []string{"public_profile"}}).GrantedScopes())
+
+	// the user declined the optional email scope
+	requested := ToScopes("public_profile", "email", "email")
+	ast.Equal([]Scope{"email"}, DeclinedScopes(requested, []Scope{"public_profile"}))
+	ast.Nil(DeclinedScopes(requested, []Scope{"email", "public_profile"}))
+
+	// step-up consent
+	ast.Equal([]Scope{"public_profile", "email"}, MergeScopes([]Scope{"public_profile"}, "email", "public_profile"))
+	ast.Equal([]Scope{"email"}, MergeScopes(nil, "email"))
+}
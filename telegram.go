@@ -0,0 +1,186 @@
+package socialite
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tgAuthorizeURL = "https://oauth.telegram.org/auth"
+
+	// default max age of auth_date
+	tgMaxAge = 24 * time.Hour
+	// tolerance of clock skew for auth_date in the future
+	tgClockSkew = time.Minute
+)
+
+// Telegram struct, the login widget is not oauth: the user data is signed with the bot token
+// Token and GetUserInfo take the raw query of the callback (id=...&first_name=...&hash=...) and verify it
+// @doc: https://core.telegram.org/widgets/login
+type Telegram struct {
+	BotToken string
+	// Origin the domain linked with the bot, e.g. https://domain.com
+	Origin      string
+	RedirectURL string
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	// MaxAge max age of auth_date (default: 24h)
+	MaxAge time.Duration
+
+	// for testing
+	now func() time.Time
+}
+
+// TgUserInfo user info
+type TgUserInfo struct {
+	ID        int64
+	FirstName string
+	LastName  string
+	Username  string
+	PhotoURL  string
+	AuthDate  time.Time
+}
+
+// botID the bot id is the part of the bot token before the colon
+func (t *Telegram) botID() string {
+	return strings.SplitN(t.BotToken, ":", 2)[0]
+}
+
+// getNow current time
+func (t *Telegram) getNow() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+// GetAuthorizeURL get the url of the login popup, args: request_access (e.g. write)
+func (t *Telegram) GetAuthorizeURL(args ...string) string {
+
+	params := map[string]string{
+		"bot_id":    t.botID(),
+		"origin":    t.Origin,
+		"return_to": t.RedirectURL,
+	}
+
+	if len(args) >= 1 && args[0] != "" {
+		params["request_access"] = args[0]
+	}
+
+	return fmt.Sprintf("%s?%s", tgAuthorizeURL, utils.QuerySortByKeyStr2(params))
+}
+
+// AuthorizeURL get the url of the login popup, scopes e.g. write are mapped to request_access,
+// the redirect url is return_to
+func (t *Telegram) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authScopes, authRedirectURL); err != nil {
+		return "", err
+	}
+	if err := checkRedirectURL(o.RedirectURL, t.RedirectURL, t.RedirectAllowlist); err != nil {
+		return "", err
+	}
+
+	params, err := o.apply(map[string]string{
+		"bot_id":    t.botID(),
+		"origin":    t.Origin,
+		"return_to": o.redirectURL(t.RedirectURL),
+	}, map[string]string{
+		"request_access": o.scope(","),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s?%s", tgAuthorizeURL, utils.QuerySortByKeyStr2(params)), nil
+}
+
+// Token verify the callback data, code is the raw query of the callback
+func (t *Telegram) Token(code string) (interface{}, error) {
+	values, err := url.ParseQuery(code)
+	if err != nil {
+		return nil, err
+	}
+	return t.VerifyValues(values)
+}
+
+// RefreshToken refresh token
+func (t *Telegram) RefreshToken(refreshToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetMe get me
+func (t *Telegram) GetMe(accessToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetUserInfo verify the callback data, accessToken is the raw query of the callback
+func (t *Telegram) GetUserInfo(accessToken, openID string) (interface{}, error) {
+	return t.Token(accessToken)
+}
+
+// VerifyValues verify the data of the callback, e.g. r.URL.Query() or r.PostForm
+func (t *Telegram) VerifyValues(values url.Values) (*TgUserInfo, error) {
+	data := make(map[string]string, len(values))
+	for k := range values {
+		data[k] = values.Get(k)
+	}
+	return t.Verify(data)
+}
+
+// Verify check the hash and the freshness of auth_date, then return the user
+// @doc: https://core.telegram.org/widgets/login#checking-authorization
+func (t *Telegram) Verify(data map[string]string) (*TgUserInfo, error) {
+
+	hash, ok := data["hash"]
+	if !ok || hash == "" {
+		return nil, errors.New("hash is missing")
+	}
+
+	secret := sha256.Sum256([]byte(t.BotToken))
+	sign := hex.EncodeToString(utils.HmacSha256(secret[:], []byte(utils.DataCheckString(data, "hash"))))
+	if !hmac.Equal([]byte(sign), []byte(strings.ToLower(hash))) {
+		return nil, errors.New("hash is invalid")
+	}
+
+	authDate, err := strconv.ParseInt(data["auth_date"], 10, 64)
+	if err != nil {
+		return nil, errors.New("auth_date is invalid")
+	}
+
+	maxAge := t.MaxAge
+	if maxAge <= 0 {
+		maxAge = tgMaxAge
+	}
+
+	issued, now := time.Unix(authDate, 0), t.getNow()
+	if now.Sub(issued) > maxAge {
+		return nil, errors.New("auth_date is expired")
+	}
+	if issued.Sub(now) > tgClockSkew {
+		return nil, errors.New("auth_date is in the future")
+	}
+
+	id, err := strconv.ParseInt(data["id"], 10, 64)
+	if err != nil {
+		return nil, errors.New("id is invalid")
+	}
+
+	return &TgUserInfo{
+		ID:        id,
+		FirstName: data["first_name"],
+		LastName:  data["last_name"],
+		Username:  data["username"],
+		PhotoURL:  data["photo_url"],
+		AuthDate:  issued,
+	}, nil
+}
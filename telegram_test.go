@@ -0,0 +1,104 @@
+package socialite
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/birjemin/socialite/utils"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var (
+	tgObj = &Telegram{
+		BotToken:    "123456:BOT_TOKEN",
+		Origin:      "https://domain.com",
+		RedirectURL: "https://domain.com/tg/callback",
+		now:         func() time.Time { return time.Unix(1600000000, 0) },
+	}
+)
+
+// tgSign sign the data as telegram does
+func tgSign(data map[string]string) string {
+	secret := sha256.Sum256([]byte("123456:BOT_TOKEN"))
+	return hex.EncodeToString(utils.HmacSha256(secret[:], []byte(utils.DataCheckString(data, "hash"))))
+}
+
+// TestTgGetAuthorizeURL test GetAuthorizeURL
+func TestTgGetAuthorizeURL(t *testing.T) {
+
+	url1 := "https://oauth.telegram.org/auth?bot_id=123456&origin=https%3A%2F%2Fdomain.com&return_to=https%3A%2F%2Fdomain.com%2Ftg%2Fcallback"
+	url2 := "https://oauth.telegram.org/auth?bot_id=123456&origin=https%3A%2F%2Fdomain.com&request_access=write&return_to=https%3A%2F%2Fdomain.com%2Ftg%2Fcallback"
+
+	ast := assert.New(t)
+
+	ast.Equal(url1, tgObj.GetAuthorizeURL())
+	ast.Equal(url2, tgObj.GetAuthorizeURL("write"))
+}
+
+// TestTgToken
+func TestTgToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	data := map[string]string{
+		"id":         "987654321",
+		"first_name": "John",
+		"username":   "john",
+		"photo_url":  "https://t.me/i/userpic/320/john.jpg",
+		"auth_date":  "1599999000",
+	}
+	data["hash"] = tgSign(data)
+
+	values := url.Values{}
+	for k, v := range data {
+		values.Set(k, v)
+	}
+
+	// success
+	resp, err := tgObj.Token(values.Encode())
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ret := resp.(*TgUserInfo)
+	ast.Equal(int64(987654321), ret.ID)
+	ast.Equal("john", ret.Username)
+	ast.Equal(int64(1599999000), ret.AuthDate.Unix())
+
+	// tampered
+	values.Set("username", "admin")
+	_, err = tgObj.Token(values.Encode())
+	ast.EqualError(err, "hash is invalid")
+
+	// missing hash
+	values.Del("hash")
+	_, err = tgObj.GetUserInfo(values.Encode(), "")
+	ast.EqualError(err, "hash is missing")
+}
+
+// TestTgVerifyAuthDate
+func TestTgVerifyAuthDate(t *testing.T) {
+
+	ast := assert.New(t)
+
+	sign := func(authDate string) map[string]string {
+		data := map[string]string{"id": "1", "first_name": "John", "auth_date": authDate}
+		data["hash"] = tgSign(data)
+		return data
+	}
+
+	_, err := tgObj.Verify(sign("1500000000"))
+	ast.EqualError(err, "auth_date is expired")
+
+	_, err = tgObj.Verify(sign("1600003600"))
+	ast.EqualError(err, "auth_date is in the future")
+
+	obj := *tgObj
+	obj.MaxAge = time.Minute
+	_, err = obj.Verify(sign("1599999000"))
+	ast.EqualError(err, "auth_date is expired")
+	_, err = obj.Verify(sign("1599999990"))
+	ast.Nil(err)
+}
@@ -0,0 +1,430 @@
+package socialite
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	jsoniter "github.com/json-iterator/go"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	twRequestTokenURL = "https://api.twitter.com/oauth/request_token"
+	twAuthorizeURL    = "https://api.twitter.com/oauth/authorize"
+	twAccessTokenURL  = "https://api.twitter.com/oauth/access_token"
+
+	twUserInfoURL = "https://api.twitter.com/1.1/account/verify_credentials.json"
+
+	twSignatureMethod = "HMAC-SHA1"
+	twVersion         = "1.0"
+)
+
+// Twitter struct, three-legged oauth 1.0a
+// @doc: https://developer.twitter.com/en/docs/authentication/oauth-1-0a/obtaining-user-access-tokens
+// GetAuthorizeURL fetches a request token and keeps its secret in SecretStore,
+// Token takes the raw query of the callback (oauth_token=...&oauth_verifier=...) as code,
+// GetUserInfo takes the oauth_token_secret of the access token as openID
+type Twitter struct {
+	ConsumerKey    string
+	ConsumerSecret string
+	RedirectURL    string
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	// SecretStore keeps request token secrets between the legs, must be shared by all instances
+	SecretStore TwSecretStore
+	HTTPRequest *utils.HTTPClient
+
+	// for testing
+	nonce     func() string
+	timestamp func() int64
+}
+
+// TwSecretStore store of request token secrets
+type TwSecretStore interface {
+	Set(token, secret string) error
+	Get(token string) (string, error)
+	Delete(token string) error
+}
+
+// TwMemorySecretStore in-memory TwSecretStore, only suitable for a single instance
+type TwMemorySecretStore struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// Set set secret
+func (s *TwMemorySecretStore) Set(token, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.secrets == nil {
+		s.secrets = make(map[string]string)
+	}
+	s.secrets[token] = secret
+	return nil
+}
+
+// Get get secret
+func (s *TwMemorySecretStore) Get(token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secret, ok := s.secrets[token]
+	if !ok {
+		return "", errors.New("request token secret is not found")
+	}
+	return secret, nil
+}
+
+// Delete delete secret
+func (s *TwMemorySecretStore) Delete(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.secrets, token)
+	return nil
+}
+
+// twRespError response of err
+type twRespError struct {
+	Errors []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// TwRespRequestToken response of request_token
+type TwRespRequestToken struct {
+	twRespError
+	OAuthToken             string
+	OAuthTokenSecret       string
+	OAuthCallbackConfirmed bool
+}
+
+// TwRespToken response of access_token
+type TwRespToken struct {
+	twRespError
+	OAuthToken       string
+	OAuthTokenSecret string
+	UserID           string
+	ScreenName       string
+}
+
+// TwUserInfo user info
+type TwUserInfo struct {
+	twRespError
+	ID                   int64  `json:"id"`
+	IDStr                string `json:"id_str"`
+	Name                 string `json:"name"`
+	ScreenName           string `json:"screen_name"`
+	Location             string `json:"location"`
+	Description          string `json:"description"`
+	URL                  string `json:"url"`
+	Protected            bool   `json:"protected"`
+	Verified             bool   `json:"verified"`
+	FollowersCount       int    `json:"followers_count"`
+	FriendsCount         int    `json:"friends_count"`
+	CreatedAt            string `json:"created_at"`
+	ProfileImageURLHTTPS string `json:"profile_image_url_https"`
+	Email                string `json:"email"`
+}
+
+// getNonce random nonce
+func (t *Twitter) getNonce() string {
+	if t.nonce != nil {
+		return t.nonce()
+	}
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// getTimestamp unix timestamp
+func (t *Twitter) getTimestamp() int64 {
+	if t.timestamp != nil {
+		return t.timestamp()
+	}
+	return time.Now().Unix()
+}
+
+// authorization header of a signed request
+// params are the query or form params of the request, oauthParams are extra oauth_* params (e.g. oauth_callback)
+func (t *Twitter) authorization(method, url string, params, oauthParams map[string]string, token, tokenSecret string) string {
+
+	oauth := map[string]string{
+		"oauth_consumer_key":     t.ConsumerKey,
+		"oauth_nonce":            t.getNonce(),
+		"oauth_signature_method": twSignatureMethod,
+		"oauth_timestamp":        strconv.FormatInt(t.getTimestamp(), 10),
+		"oauth_version":          twVersion,
+	}
+	if token != "" {
+		oauth["oauth_token"] = token
+	}
+	for k, v := range oauthParams {
+		oauth[k] = v
+	}
+
+	all := make(map[string]string, len(params)+len(oauth))
+	for k, v := range params {
+		all[k] = v
+	}
+	for k, v := range oauth {
+		all[k] = v
+	}
+
+	base := utils.OAuthSignatureBase(method, url, all)
+	oauth["oauth_signature"] = utils.OAuthHmacSha1Sign(base, t.ConsumerSecret, tokenSecret)
+
+	keys := make([]string, 0, len(oauth))
+	for k := range oauth {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, utils.OAuthEscape(k), utils.OAuthEscape(oauth[k])))
+	}
+	return "OAuth " + strings.Join(pairs, ", ")
+}
+
+// parseError parse the json errors of twitter
+func (t *Twitter) parseError(b []byte, ret *twRespError) error {
+	if err := jsoniter.Unmarshal(b, ret); err != nil {
+		return errors.New("response is invalid")
+	}
+	if len(ret.Errors) > 0 {
+		return errors.New(ret.Errors[0].Message)
+	}
+	return errors.New("response is invalid")
+}
+
+// RequestToken get a request token (step 1)
+func (t *Twitter) RequestToken() (*TwRespRequestToken, error) {
+	return t.doRequestToken(twRequestTokenURL)
+}
+
+// doRequestToken handle
+func (t *Twitter) doRequestToken(url string) (*TwRespRequestToken, error) {
+
+	headers := map[string]string{
+		"Authorization": t.authorization("POST", url, nil, map[string]string{"oauth_callback": t.RedirectURL}, "", ""),
+	}
+
+	if err := t.HTTPRequest.HTTPPostWithHeader(url, nil, headers); err != nil {
+		return nil, err
+	}
+
+	b, err := t.HTTPRequest.GetResponseByte()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := new(TwRespRequestToken)
+	values, err := parseQuery(b)
+	if err != nil || values.Get("oauth_token") == "" {
+		return ret, t.parseError(b, &ret.twRespError)
+	}
+
+	ret.OAuthToken = values.Get("oauth_token")
+	ret.OAuthTokenSecret = values.Get("oauth_token_secret")
+	ret.OAuthCallbackConfirmed = values.Get("oauth_callback_confirmed") == "true"
+	return ret, nil
+}
+
+// RequestAuthorizeURL get a request token, keep its secret and build the authorize url (step 2)
+func (t *Twitter) RequestAuthorizeURL() (string, error) {
+	return t.doRequestAuthorizeURL(twRequestTokenURL)
+}
+
+// doRequestAuthorizeURL handle
+func (t *Twitter) doRequestAuthorizeURL(url string) (string, error) {
+
+	if t.SecretStore == nil {
+		return "", errors.New("secret store is required")
+	}
+
+	ret, err := t.doRequestToken(url)
+	if err != nil {
+		return "", err
+	}
+	if !ret.OAuthCallbackConfirmed {
+		return "", errors.New("oauth callback is not confirmed")
+	}
+
+	if err := t.SecretStore.Set(ret.OAuthToken, ret.OAuthTokenSecret); err != nil {
+		return "", err
+	}
+	return t.GetAuthorizeURL(ret.OAuthToken), nil
+}
+
+// GetAuthorizeURL get authorize url, args: oauth_token
+// without oauth_token a request token is fetched first, an empty string is returned when it fails (see RequestAuthorizeURL)
+func (t *Twitter) GetAuthorizeURL(args ...string) string {
+
+	if len(args) < 1 || args[0] == "" {
+		ret, err := t.RequestAuthorizeURL()
+		if err != nil {
+			return ""
+		}
+		return ret
+	}
+
+	params := map[string]string{
+		"oauth_token": args[0],
+	}
+
+	return fmt.Sprintf("%s?%s", twAuthorizeURL, utils.QuerySortByKeyStr2(params))
+}
+
+// AuthorizeURL fetch a request token and get authorize url, the redirect url is the oauth_callback,
+// login hint is mapped to screen_name and prompt login to force_login
+func (t *Twitter) AuthorizeURL(opts ...AuthOption) (string, error) {
+	return t.doAuthorizeURL(twRequestTokenURL, opts...)
+}
+
+// doAuthorizeURL handle
+func (t *Twitter) doAuthorizeURL(url string, opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authRedirectURL, authLoginHint, authPrompt); err != nil {
+		return "", err
+	}
+	if err := checkRedirectURL(o.RedirectURL, t.RedirectURL, t.RedirectAllowlist); err != nil {
+		return "", err
+	}
+	if o.Prompt != "" && o.Prompt != "login" {
+		return "", fmt.Errorf("prompt %q is invalid", o.Prompt)
+	}
+
+	client := *t
+	client.RedirectURL = o.redirectURL(t.RedirectURL)
+	ret, err := client.doRequestAuthorizeURL(url)
+	if err != nil {
+		return "", err
+	}
+
+	if o.LoginHint != "" {
+		WithExtra("screen_name", o.LoginHint)(o)
+	}
+	if o.Prompt == "login" {
+		WithExtra("force_login", "true")(o)
+	}
+	return o.withExtra(ret)
+}
+
+// Token exchange the request token for an access token (step 3)
+// code is the raw query of the callback: oauth_token=...&oauth_verifier=...
+func (t *Twitter) Token(code string) (interface{}, error) {
+	return t.doToken(twAccessTokenURL, code)
+}
+
+// doToken handle
+func (t *Twitter) doToken(url, code string) (*TwRespToken, error) {
+
+	if t.SecretStore == nil {
+		return nil, errors.New("secret store is required")
+	}
+
+	callback, err := parseQuery([]byte(code))
+	if err != nil {
+		return nil, err
+	}
+
+	token, verifier := callback.Get("oauth_token"), callback.Get("oauth_verifier")
+	if token == "" || verifier == "" {
+		return nil, errors.New("oauth_token or oauth_verifier is missing")
+	}
+
+	secret, err := t.SecretStore.Get(token)
+	if err != nil {
+		return nil, err
+	}
+	// the request token can only be exchanged once
+	_ = t.SecretStore.Delete(token)
+
+	return t.doAccessToken(url, token, secret, verifier)
+}
+
+// AccessToken exchange a request token and its verifier for an access token
+func (t *Twitter) AccessToken(token, tokenSecret, verifier string) (*TwRespToken, error) {
+	return t.doAccessToken(twAccessTokenURL, token, tokenSecret, verifier)
+}
+
+// doAccessToken handle
+func (t *Twitter) doAccessToken(url, token, tokenSecret, verifier string) (*TwRespToken, error) {
+
+	headers := map[string]string{
+		"Authorization": t.authorization("POST", url, nil, map[string]string{"oauth_verifier": verifier}, token, tokenSecret),
+	}
+
+	if err := t.HTTPRequest.HTTPPostWithHeader(url, nil, headers, utils.NonIdempotent()); err != nil {
+		return nil, err
+	}
+
+	b, err := t.HTTPRequest.GetResponseByte()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := new(TwRespToken)
+	values, err := parseQuery(b)
+	if err != nil || values.Get("oauth_token") == "" {
+		return ret, t.parseError(b, &ret.twRespError)
+	}
+
+	ret.OAuthToken = values.Get("oauth_token")
+	ret.OAuthTokenSecret = values.Get("oauth_token_secret")
+	ret.UserID = values.Get("user_id")
+	ret.ScreenName = values.Get("screen_name")
+	return ret, nil
+}
+
+// RefreshToken oauth 1.0a tokens do not expire
+func (t *Twitter) RefreshToken(refreshToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetMe get me
+func (t *Twitter) GetMe(accessToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetUserInfo get user info, openID is the oauth_token_secret of the access token
+func (t *Twitter) GetUserInfo(accessToken, openID string) (interface{}, error) {
+	return t.doGetUserInfo(twUserInfoURL, accessToken, openID)
+}
+
+// doGetUserInfo handle
+func (t *Twitter) doGetUserInfo(url, accessToken, tokenSecret string) (*TwUserInfo, error) {
+
+	params := map[string]string{
+		"include_email":    "true",
+		"skip_status":      "true",
+		"include_entities": "false",
+	}
+
+	headers := map[string]string{
+		"Authorization": t.authorization("GET", url, params, nil, accessToken, tokenSecret),
+	}
+
+	if err := t.HTTPRequest.HTTPGetWithHeader(url, params, headers); err != nil {
+		return nil, err
+	}
+
+	ret := new(TwUserInfo)
+	if err := t.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// parseQuery parse a form encoded body
+func parseQuery(b []byte) (url.Values, error) {
+	return url.ParseQuery(strings.TrimSpace(string(b)))
+}
@@ -0,0 +1,233 @@
+package socialite
+
+import (
+	"github.com/birjemin/socialite/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	twHTTPClient = &utils.HTTPClient{
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	twObj = &Twitter{
+		ConsumerKey:    "CONSUMER_KEY",
+		ConsumerSecret: "CONSUMER_SECRET",
+		RedirectURL:    "http://localhost/callback",
+		SecretStore:    &TwMemorySecretStore{},
+		HTTPRequest:    twHTTPClient,
+		nonce:          func() string { return "NONCE" },
+		timestamp:      func() int64 { return 1318622958 },
+	}
+)
+
+// twVerify verify the oauth signature of a request of the fake server
+func twVerify(r *http.Request, tokenSecret string) (map[string]string, bool) {
+
+	header := strings.TrimPrefix(r.Header.Get("Authorization"), "OAuth ")
+	oauth := make(map[string]string)
+	for _, pair := range strings.Split(header, ", ") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, false
+		}
+		v, _ := url.PathUnescape(strings.Trim(kv[1], `"`))
+		oauth[kv[0]] = v
+	}
+
+	params := make(map[string]string)
+	for k, v := range r.URL.Query() {
+		params[k] = v[0]
+	}
+	for k, v := range oauth {
+		if k != "oauth_signature" {
+			params[k] = v
+		}
+	}
+
+	base := utils.OAuthSignatureBase(r.Method, "http://"+r.Host+r.URL.Path, params)
+	return oauth, oauth["oauth_signature"] == utils.OAuthHmacSha1Sign(base, "CONSUMER_SECRET", tokenSecret)
+}
+
+// TestTwRequestAuthorizeURL
+func TestTwRequestAuthorizeURL(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `oauth_token=REQUEST_TOKEN&oauth_token_secret=REQUEST_SECRET&oauth_callback_confirmed=true`
+		oauth, ok := twVerify(r, "")
+		if !ok || oauth["oauth_callback"] != "http://localhost/callback" {
+			ret = `{"errors":[{"code":32,"message":"Could not authenticate you."}]}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := twObj.doRequestAuthorizeURL(ts.URL)
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("https://api.twitter.com/oauth/authorize?oauth_token=REQUEST_TOKEN", ret)
+
+	secret, err := twObj.SecretStore.Get("REQUEST_TOKEN")
+	ast.Nil(err)
+	ast.Equal("REQUEST_SECRET", secret)
+
+	// fail
+	obj := *twObj
+	obj.ConsumerSecret = "WRONG"
+	_, err = obj.doRequestAuthorizeURL(ts.URL)
+	ast.EqualError(err, "Could not authenticate you.")
+
+	obj.SecretStore = nil
+	_, err = obj.doRequestAuthorizeURL(ts.URL)
+	ast.EqualError(err, "secret store is required")
+}
+
+// TestTwAuthorizeURL
+func TestTwAuthorizeURL(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `oauth_token=REQUEST_TOKEN&oauth_token_secret=REQUEST_SECRET&oauth_callback_confirmed=true`
+		oauth, ok := twVerify(r, "")
+		if !ok || oauth["oauth_callback"] != "https://a.domain.com/tw/callback" {
+			ret = `{"errors":[{"code":32,"message":"Could not authenticate you."}]}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	obj := *twObj
+	obj.RedirectAllowlist = RedirectAllowlist{"https://*.domain.com/tw/callback"}
+
+	// success
+	ret, err := obj.doAuthorizeURL(ts.URL, WithRedirectURL("https://a.domain.com/tw/callback"), WithLoginHint("screen_name"), WithPrompt("login"))
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("https://api.twitter.com/oauth/authorize?oauth_token=REQUEST_TOKEN&force_login=true&screen_name=screen_name", ret)
+	// the default one is kept
+	ast.Equal("http://localhost/callback", obj.RedirectURL)
+
+	// fail
+	_, err = obj.doAuthorizeURL(ts.URL)
+	ast.EqualError(err, "Could not authenticate you.")
+
+	_, err = obj.doAuthorizeURL(ts.URL, WithPrompt("consent"))
+	ast.Error(err)
+
+	_, err = obj.doAuthorizeURL(ts.URL, WithRedirectURL("https://domain.com/tw/callback"))
+	ast.EqualError(err, "redirect url is not allowed")
+}
+
+// TestTwToken
+func TestTwToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `oauth_token=ACCESS_TOKEN&oauth_token_secret=ACCESS_SECRET&user_id=6253282&screen_name=twitterapi`
+		oauth, ok := twVerify(r, "REQUEST_SECRET")
+		if !ok || oauth["oauth_token"] != "REQUEST_TOKEN" || oauth["oauth_verifier"] != "VERIFIER" {
+			ret = `{"errors":[{"code":89,"message":"Invalid or expired token."}]}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	ast.Nil(twObj.SecretStore.Set("REQUEST_TOKEN", "REQUEST_SECRET"))
+
+	// success
+	ret, err := twObj.doToken(ts.URL, "oauth_token=REQUEST_TOKEN&oauth_verifier=VERIFIER")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("ACCESS_TOKEN", ret.OAuthToken)
+	ast.Equal("ACCESS_SECRET", ret.OAuthTokenSecret)
+	ast.Equal("6253282", ret.UserID)
+	ast.Equal("twitterapi", ret.ScreenName)
+
+	// the request token secret is consumed
+	_, err = twObj.doToken(ts.URL, "oauth_token=REQUEST_TOKEN&oauth_verifier=VERIFIER")
+	ast.EqualError(err, "request token secret is not found")
+
+	// fail
+	_, err = twObj.doToken(ts.URL, "oauth_token=REQUEST_TOKEN")
+	ast.EqualError(err, "oauth_token or oauth_verifier is missing")
+
+	_, err = twObj.doAccessToken(ts.URL, "REQUEST_TOKEN", "REQUEST_SECRET", "WRONG")
+	ast.EqualError(err, "Invalid or expired token.")
+}
+
+// TestTwUserInfo
+func TestTwUserInfo(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"id":6253282,"id_str":"6253282","name":"Twitter API","screen_name":"TwitterAPI","email":"a@b.com","profile_image_url_https":"https://pbs.twimg.com/profile_images/normal.jpg"}`
+		oauth, ok := twVerify(r, "ACCESS_SECRET")
+		if !ok || oauth["oauth_token"] != "ACCESS_TOKEN" || r.FormValue("include_email") != "true" {
+			ret = `{"errors":[{"code":32,"message":"Could not authenticate you."}]}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := twObj.doGetUserInfo(ts.URL, "ACCESS_TOKEN", "ACCESS_SECRET")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(int64(6253282), ret.ID)
+	ast.Equal("TwitterAPI", ret.ScreenName)
+	ast.Equal("a@b.com", ret.Email)
+
+	// fail
+	ret, err = twObj.doGetUserInfo(ts.URL, "ACCESS_TOKEN", "WRONG")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(32, ret.Errors[0].Code)
+}
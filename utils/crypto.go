@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// HmacSha256 hmac-sha256 of msg keyed with key
+func HmacSha256(key, msg []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(msg)
+	return mac.Sum(nil)
+}
+
+// HmacSha256Hex hex encoded hmac-sha256
+func HmacSha256Hex(key, msg string) string {
+	return hex.EncodeToString(HmacSha256([]byte(key), []byte(msg)))
+}
+
+// AesCBCDecrypt aes-cbc decrypt and remove the pkcs#7 padding
+func AesCBCDecrypt(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("iv length is invalid")
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("data is not a multiple of the block size")
+	}
+
+	ret := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(ret, data)
+	return PKCS7Unpad(ret)
+}
+
+// PKCS7Unpad remove the pkcs#7 padding (up to 32 bytes, as wechat pads to 32)
+func PKCS7Unpad(data []byte) ([]byte, error) {
+	length := len(data)
+	if length == 0 {
+		return nil, errors.New("padding is invalid")
+	}
+
+	pad := int(data[length-1])
+	if pad < 1 || pad > 32 || pad > length {
+		return nil, errors.New("padding is invalid")
+	}
+	for _, b := range data[length-pad:] {
+		if int(b) != pad {
+			return nil, errors.New("padding is invalid")
+		}
+	}
+	return data[:length-pad], nil
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHmacSha256Hex(t *testing.T) {
+	ast := assert.New(t)
+	ast.Equal("f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", HmacSha256Hex("key", "The quick brown fox jumps over the lazy dog"))
+}
+
+func TestAesCBCDecrypt(t *testing.T) {
+	ast := assert.New(t)
+
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+
+	// "hello" with pkcs#7 padding
+	plain := append([]byte("hello"), 11, 11, 11, 11, 11, 11, 11, 11, 11, 11, 11)
+	block, _ := aes.NewCipher(key)
+	data := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, plain)
+
+	ret, err := AesCBCDecrypt(key, iv, data)
+	ast.Nil(err)
+	ast.Equal([]byte("hello"), ret)
+
+	_, err = AesCBCDecrypt(key, iv[:8], data)
+	ast.Error(err)
+	_, err = AesCBCDecrypt(key, iv, data[:8])
+	ast.Error(err)
+	_, err = AesCBCDecrypt([]byte("short"), iv, data)
+	ast.Error(err)
+}
+
+func TestPKCS7Unpad(t *testing.T) {
+	ast := assert.New(t)
+
+	ret, err := PKCS7Unpad([]byte{'a', 2, 2})
+	ast.Nil(err)
+	ast.Equal([]byte("a"), ret)
+
+	_, err = PKCS7Unpad([]byte{'a', 1, 2})
+	ast.Error(err)
+	_, err = PKCS7Unpad([]byte{'a', 0})
+	ast.Error(err)
+	_, err = PKCS7Unpad([]byte{})
+	ast.Error(err)
+}
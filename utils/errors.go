@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxErrorBody max length of the body kept by HTTPStatusError
+const maxErrorBody = 512
+
+// redactedKeys query params whose values are replaced, matched by substring
+var redactedKeys = []string{"token", "secret", "code", "password", "signature", "verifier", "key"}
+
+// HTTPStatusError a non-2xx response whose body can not be decoded, e.g. an html error page of a gateway
+type HTTPStatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Body the beginning of the body
+	Body string
+}
+
+// Error
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("http %s %s: status %d: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// Temporary whether the request may succeed later, 429 and 5xx
+func (e *HTTPStatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// newHTTPStatusError error of the response with the beginning of the body
+func newHTTPStatusError(resp *http.Response, body []byte) *HTTPStatusError {
+
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+
+	ret := &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+	}
+	if resp.Request != nil {
+		ret.Method = resp.Request.Method
+		ret.URL = RedactURL(resp.Request.URL)
+	}
+	return ret
+}
+
+// requestError wrap the transport error with the method and the redacted url, the url.Error of
+// net/http is dropped because its url is not redacted
+func requestError(req *http.Request, err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return fmt.Errorf("http %s %s: %w", req.Method, RedactURL(req.URL), err)
+}
+
+// RedactURL the url without userinfo and with the values of secrets (tokens, codes, ...) replaced
+func RedactURL(u *url.URL) string {
+
+	if u == nil {
+		return ""
+	}
+
+	ret := *u
+	ret.User = nil
+	if ret.RawQuery != "" {
+		query := ret.Query()
+		for k := range query {
+			if isRedactedKey(k) {
+				query[k] = []string{"REDACTED"}
+			}
+		}
+		ret.RawQuery = query.Encode()
+	}
+	return ret.String()
+}
+
+// isRedactedKey whether the value of the query param is a secret
+func isRedactedKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, v := range redactedKeys {
+		if strings.Contains(key, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTimeout whether the request failed because of a timeout
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var t interface{ Timeout() bool }
+	return errors.As(err, &t) && t.Timeout()
+}
+
+// IsTemporary whether the request may succeed when it is sent again, e.g. a timeout,
+// a reset connection or a 5xx error page
+func IsTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+	if IsTimeout(err) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	jsoniter "github.com/json-iterator/go"
 	"io/ioutil"
 	"net/http"
@@ -13,62 +15,84 @@ import (
 type HTTPClient struct {
 	Client   *http.Client
 	Response *http.Response
+	// Retry retry policy of the requests, no retry when it is nil
+	Retry *RetryPolicy
 }
 
 // HTTPGet get method
-func (c *HTTPClient) HTTPGet(url string, params map[string]string) error {
+func (c *HTTPClient) HTTPGet(url string, params map[string]string, opts ...RequestOption) error {
+	return c.HTTPGetWithHeader(url, params, nil, opts...)
+}
+
+// HTTPGetWithHeader get method with extra headers (e.g. Authorization)
+func (c *HTTPClient) HTTPGetWithHeader(url string, params, headers map[string]string, opts ...RequestOption) error {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return errors.New("sending http get request error")
+		return fmt.Errorf("new http GET request: %w", err)
 	}
 	req.URL.RawQuery = HTTPQueryBuild(params)
-	if c.Response, err = c.Client.Do(req); err != nil {
-		return errors.New("http get response error")
+	for k, v := range headers {
+		req.Header.Set(k, v)
 	}
-	return nil
+	return c.do(req, opts)
 }
 
 // HTTPPost post string
-func (c *HTTPClient) HTTPPost(url string, params map[string]string) error {
+func (c *HTTPClient) HTTPPost(url string, params map[string]string, opts ...RequestOption) error {
+	return c.HTTPPostWithHeader(url, params, nil, opts...)
+}
+
+// HTTPPostWithHeader post string with extra headers (e.g. Authorization)
+func (c *HTTPClient) HTTPPostWithHeader(url string, params, headers map[string]string, opts ...RequestOption) error {
 	var query = HTTPQueryBuild(params)
 
-	return c.doPostRequest(url, query, "application/x-www-form-urlencoded;charset=UTF-8")
+	return c.doPostRequest(url, query, "application/x-www-form-urlencoded;charset=UTF-8", headers, opts...)
 }
 
 // HTTPPostJSON post json
-func (c *HTTPClient) HTTPPostJSON(url, jsonStr string) error {
-	return c.doPostRequest(url, jsonStr, "application/json;charset=UTF-8")
+func (c *HTTPClient) HTTPPostJSON(url, jsonStr string, opts ...RequestOption) error {
+	return c.doPostRequest(url, jsonStr, "application/json;charset=UTF-8", nil, opts...)
 }
 
 // doPostRequest
-func (c *HTTPClient) doPostRequest(url, str, contentType string) (err error) {
+func (c *HTTPClient) doPostRequest(url, str, contentType string, headers map[string]string, opts ...RequestOption) (err error) {
 	var req *http.Request
 	if req, err = http.NewRequest("POST", url, strings.NewReader(str)); err != nil {
-		return errors.New("sending http request error")
+		return fmt.Errorf("new http POST request: %w", err)
 	}
 	req.Header.Set("Content-Type", contentType)
-	c.Response, err = c.Client.Do(req)
-	return
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return c.do(req, opts)
 }
 
-// GetResponseJSON get response json
+// GetResponseJSON get response json, HTTPStatusError for a non-2xx response which is not json
 func (c *HTTPClient) GetResponseJSON(response interface{}) error {
-	if c.Response.Body == nil {
-		return errors.New("http request response body is empty")
+	body, err := c.GetResponseByte()
+	if err != nil {
+		return err
 	}
 
 	var json = jsoniter.ConfigCompatibleWithStandardLibrary
-	defer c.Response.Body.Close()
-	return json.NewDecoder(c.Response.Body).Decode(response)
+	return json.NewDecoder(bytes.NewReader(body)).Decode(response)
 }
 
-// GetResponseByte get response byte
+// GetResponseByte get response byte, HTTPStatusError for a non-2xx response which is not json,
+// the json errors of the providers are left to the callers
 func (c *HTTPClient) GetResponseByte() (body []byte, err error) {
 	if c.Response.Body == nil {
 		return []byte{}, errors.New("http request response body is empty")
 	}
 	defer c.Response.Body.Close()
-	return ioutil.ReadAll(c.Response.Body)
+
+	if body, err = ioutil.ReadAll(c.Response.Body); err != nil {
+		return body, fmt.Errorf("read http response: %w", err)
+	}
+	if code := c.Response.StatusCode; (code < 200 || code > 299) && !jsoniter.Valid(body) {
+		return body, newHTTPStatusError(c.Response, body)
+	}
+	return body, nil
 }
 
 // HTTPQueryBuild http_query_build
@@ -147,3 +147,39 @@ func TestHTTPQueryBuild(t *testing.T) {
 	ret := HTTPQueryBuild(map[string]string{"b": "11", "a": "22"})
 	ast.Equal(ret, "a=22&b=11")
 }
+
+// TestGetWithHeader
+func TestGetWithHeader(t *testing.T) {
+	ast := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s %s", r.Header.Get("Authorization"), r.FormValue("param"))
+	}))
+	defer ts.Close()
+
+	c := &HTTPClient{
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	if err := c.HTTPGetWithHeader(ts.URL, map[string]string{"param": "get"}, map[string]string{"Authorization": "Bearer token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ret, err := c.GetResponseByte(); err != nil {
+		t.Fatal(err)
+	} else {
+		ast.Equal([]byte("Bearer token get"), ret)
+	}
+
+	if err := c.HTTPPostWithHeader(ts.URL, map[string]string{"param": "post"}, map[string]string{"Authorization": "Bearer token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ret, err := c.GetResponseByte(); err != nil {
+		t.Fatal(err)
+	} else {
+		ast.Equal([]byte("Bearer token post"), ret)
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	jsoniter "github.com/json-iterator/go"
+	"strings"
+)
+
+// DecodeJWT decode the payload segment of a jwt into claims (the signature is not verified)
+func DecodeJWT(token string, claims interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("jwt is malformed")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return errors.New("jwt payload is not base64url encoded")
+	}
+
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	return json.Unmarshal(payload, claims)
+}
+
+// VerifyJWTHS256 verify the HS256 signature of a jwt with secret and decode its payload into claims
+func VerifyJWTHS256(token, secret string, claims interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("jwt is malformed")
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+	}{}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[0], "="))
+	if err != nil {
+		return errors.New("jwt header is not base64url encoded")
+	}
+	if err := jsoniter.Unmarshal(b, &header); err != nil || header.Alg != "HS256" {
+		return errors.New("jwt algorithm is not HS256")
+	}
+
+	sign, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return errors.New("jwt signature is not base64url encoded")
+	}
+	if !hmac.Equal(sign, HmacSha256([]byte(secret), []byte(parts[0]+"."+parts[1]))) {
+		return errors.New("jwt signature is invalid")
+	}
+
+	return DecodeJWT(token, claims)
+}
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	jsoniter "github.com/json-iterator/go"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// default values of RetryPolicy
+const (
+	defaultRetryBaseDelay = 100 * time.Millisecond
+	defaultRetryMaxDelay  = 2 * time.Second
+)
+
+// defaultRetryStatusCodes retryable http status codes by default
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy retry of the requests which failed with a transport error, a retryable status code
+// or a retryable provider error code, the delay is doubled for each retry with a jitter
+type RetryPolicy struct {
+	// MaxAttempts attempts including the first one, no retry when it is less than 2
+	MaxAttempts int
+	// BaseDelay delay of the first retry, 100ms by default
+	BaseDelay time.Duration
+	// MaxDelay max delay of a retry, 2s by default
+	MaxDelay time.Duration
+	// StatusCodes retryable http status codes, 429, 500, 502, 503 and 504 by default
+	StatusCodes []int
+	// RetryBody whether the body is a retryable provider error, e.g. RetryOnErrCodes(-1) for wechat's system busy
+	RetryBody func(body []byte) bool
+}
+
+// RequestOption option of a request
+type RequestOption func(*requestOptions)
+
+// requestOptions options of a request
+type requestOptions struct {
+	nonIdempotent bool
+	ctx           context.Context
+}
+
+// WithContext context of the request, no retry is made and the wait for the next attempt ends
+// once it is done
+func WithContext(ctx context.Context) RequestOption {
+	return func(o *requestOptions) {
+		o.ctx = ctx
+	}
+}
+
+// NonIdempotent the request must not be repeated once it reached the server, e.g. exchanging a code
+// which is consumed by the first request, it is only retried when the connection can not be made
+func NonIdempotent() RequestOption {
+	return func(o *requestOptions) {
+		o.nonIdempotent = true
+	}
+}
+
+// RetryOnErrCodes retry the responses with the error codes, read from errcode (wechat), ret (qq)
+// and error_code (weibo)
+func RetryOnErrCodes(codes ...int) func(body []byte) bool {
+	return func(body []byte) bool {
+		// qq may wrap the json with a callback
+		start, end := bytes.IndexByte(body, '{'), bytes.LastIndexByte(body, '}')
+		if start < 0 || end < start {
+			return false
+		}
+
+		var ret struct {
+			ErrCode   *int `json:"errcode"`
+			Ret       *int `json:"ret"`
+			ErrorCode *int `json:"error_code"`
+		}
+		var json = jsoniter.ConfigCompatibleWithStandardLibrary
+		if err := json.Unmarshal(body[start:end+1], &ret); err != nil {
+			return false
+		}
+		for _, v := range []*int{ret.ErrCode, ret.Ret, ret.ErrorCode} {
+			if v == nil {
+				continue
+			}
+			for _, code := range codes {
+				if *v == code {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// maxAttempts attempts of a request, 1 without a policy
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// delay delay before the next attempt, half of it is the jitter
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	if max <= 0 {
+		max = defaultRetryMaxDelay
+	}
+
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryStatus whether the status code is retryable
+func (p *RetryPolicy) retryStatus(code int) bool {
+	codes := p.StatusCodes
+	if codes == nil {
+		codes = defaultRetryStatusCodes
+	}
+	for _, v := range codes {
+		if v == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryResponse whether the response is retryable, the body is restored when it is read
+func (p *RetryPolicy) retryResponse(resp *http.Response) bool {
+
+	if p.retryStatus(resp.StatusCode) {
+		return true
+	}
+	if p.RetryBody == nil || resp.Body == nil {
+		return false
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return true
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return p.RetryBody(body)
+}
+
+// retryError whether the transport error is retryable, the request is not retried once its context
+// is done or when the certificate is rejected, a non-idempotent request is only retried when the
+// connection can not be made
+func retryError(req *http.Request, err error, o *requestOptions) bool {
+	if req.Context().Err() != nil || isCertificateError(err) {
+		return false
+	}
+	if !o.nonIdempotent {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isCertificateError whether the certificate of the server is rejected, it fails again when retried
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	return errors.As(err, &unknownAuthority) || errors.As(err, &invalid) || errors.As(err, &hostname)
+}
+
+// wait wait for the delay, the error of the context when it is done first
+func wait(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// discard read and close the body so that the connection can be reused
+func discard(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// do send the request with the retry policy, the body is rewound for each retry and the wait
+// between the attempts ends with the context of the request
+func (c *HTTPClient) do(req *http.Request, opts []RequestOption) (err error) {
+
+	o := new(requestOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.ctx != nil {
+		req = req.WithContext(o.ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return
+			}
+		}
+
+		c.Response, err = c.Client.Do(req)
+		if err != nil {
+			err = requestError(req, err)
+		}
+		if attempt >= c.Retry.maxAttempts() {
+			return
+		}
+		if err != nil {
+			if !retryError(req, err, o) {
+				return
+			}
+		} else if o.nonIdempotent || !c.Retry.retryResponse(c.Response) {
+			return
+		} else {
+			discard(c.Response)
+		}
+
+		if werr := wait(req.Context(), c.Retry.delay(attempt)); werr != nil {
+			return requestError(req, werr)
+		}
+	}
+}
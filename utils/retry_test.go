@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roundTripFunc transport of the tests
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// flakyServer fail the first requests with the status code and the body
+func flakyServer(failures int32, code int, body string) (*httptest.Server, *int32) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= failures {
+			w.WriteHeader(code)
+			_, _ = fmt.Fprint(w, body)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"errcode":0,"param":"%s"}`, r.FormValue("param"))
+	}))
+	return ts, &count
+}
+
+// retryClient client with 3 attempts and short delays
+func retryClient(p *RetryPolicy) *HTTPClient {
+	p.MaxAttempts = 3
+	p.BaseDelay = time.Millisecond
+	p.MaxDelay = 5 * time.Millisecond
+	return &HTTPClient{
+		Client: &http.Client{Timeout: 5 * time.Second},
+		Retry:  p,
+	}
+}
+
+// TestRetryStatus
+func TestRetryStatus(t *testing.T) {
+
+	ast := assert.New(t)
+
+	// success
+	ts, count := flakyServer(2, http.StatusServiceUnavailable, "busy")
+	defer ts.Close()
+
+	c := retryClient(&RetryPolicy{})
+	ast.Nil(c.HTTPPost(ts.URL, map[string]string{"param": "post"}))
+	ret, err := c.GetResponseByte()
+	ast.Nil(err)
+	ast.Equal(`{"errcode":0,"param":"post"}`, string(ret))
+	ast.Equal(int32(3), atomic.LoadInt32(count))
+
+	// the attempts are used up, the last response is returned
+	ts2, count2 := flakyServer(5, http.StatusBadGateway, "bad gateway")
+	defer ts2.Close()
+
+	ast.Nil(c.HTTPGet(ts2.URL, nil))
+	ast.Equal(http.StatusBadGateway, c.Response.StatusCode)
+	ret, err = c.GetResponseByte()
+	ast.Equal("bad gateway", string(ret))
+	ast.True(IsTemporary(err))
+	ast.Equal(int32(3), atomic.LoadInt32(count2))
+
+	// not retryable
+	ts3, count3 := flakyServer(1, http.StatusBadRequest, "bad request")
+	defer ts3.Close()
+
+	ast.Nil(c.HTTPGet(ts3.URL, nil))
+	ast.Equal(http.StatusBadRequest, c.Response.StatusCode)
+	ast.Equal(int32(1), atomic.LoadInt32(count3))
+
+	// no policy
+	ts4, count4 := flakyServer(1, http.StatusServiceUnavailable, "busy")
+	defer ts4.Close()
+
+	c = &HTTPClient{Client: &http.Client{Timeout: 5 * time.Second}}
+	ast.Nil(c.HTTPGet(ts4.URL, nil))
+	ast.Equal(http.StatusServiceUnavailable, c.Response.StatusCode)
+	ast.Equal(int32(1), atomic.LoadInt32(count4))
+}
+
+// TestRetryErrCodes
+func TestRetryErrCodes(t *testing.T) {
+
+	ast := assert.New(t)
+
+	ts, count := flakyServer(2, http.StatusOK, `{"errcode":-1,"errmsg":"system busy"}`)
+	defer ts.Close()
+
+	c := retryClient(&RetryPolicy{RetryBody: RetryOnErrCodes(-1)})
+	ast.Nil(c.HTTPGet(ts.URL, map[string]string{"param": "get"}))
+
+	var ret struct {
+		ErrCode int    `json:"errcode"`
+		Param   string `json:"param"`
+	}
+	ast.Nil(c.GetResponseJSON(&ret))
+	ast.Equal(0, ret.ErrCode)
+	ast.Equal("get", ret.Param)
+	ast.Equal(int32(3), atomic.LoadInt32(count))
+
+	// the body of a response which is not retried can still be read
+	ts2, count2 := flakyServer(1, http.StatusOK, `{"errcode":40029,"errmsg":"invalid code"}`)
+	defer ts2.Close()
+
+	ast.Nil(c.HTTPGet(ts2.URL, nil))
+	ast.Nil(c.GetResponseJSON(&ret))
+	ast.Equal(40029, ret.ErrCode)
+	ast.Equal(int32(1), atomic.LoadInt32(count2))
+
+	retry := RetryOnErrCodes(-1, 100000)
+	ast.True(retry([]byte(`callback( {"ret":-1,"msg":"busy"} );`)))
+	ast.True(retry([]byte(`{"error_code":100000,"error":"system busy"}`)))
+	ast.False(retry([]byte(`{"errcode":0}`)))
+	ast.False(retry([]byte(`access_token=TOKEN`)))
+	ast.False(retry([]byte(`{"errcode":"-1"}`)))
+}
+
+// TestRetryNonIdempotent
+func TestRetryNonIdempotent(t *testing.T) {
+
+	ast := assert.New(t)
+
+	// the code may be consumed, the response is not retried
+	ts, count := flakyServer(2, http.StatusServiceUnavailable, "busy")
+	defer ts.Close()
+
+	c := retryClient(&RetryPolicy{})
+	ast.Nil(c.HTTPPost(ts.URL, map[string]string{"param": "code"}, NonIdempotent()))
+	ast.Equal(http.StatusServiceUnavailable, c.Response.StatusCode)
+	ast.Equal(int32(1), atomic.LoadInt32(count))
+}
+
+// TestRetryTransport
+func TestRetryTransport(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var count int32
+	fail := func(err error) *HTTPClient {
+		atomic.StoreInt32(&count, 0)
+		c := retryClient(&RetryPolicy{})
+		c.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&count, 1)
+			return nil, err
+		})
+		return c
+	}
+
+	reset := errors.New("connection reset by peer")
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	// idempotent requests are retried for any transport error
+	ast.Error(fail(reset).HTTPGet("http://domain.com", nil))
+	ast.Equal(int32(3), atomic.LoadInt32(&count))
+
+	// non-idempotent requests are only retried when the connection can not be made
+	ast.Error(fail(reset).HTTPPost("http://domain.com", nil, NonIdempotent()))
+	ast.Equal(int32(1), atomic.LoadInt32(&count))
+
+	ast.Error(fail(dial).HTTPPost("http://domain.com", nil, NonIdempotent()))
+	ast.Equal(int32(3), atomic.LoadInt32(&count))
+
+	// the body is sent again
+	var bodies []string
+	c := retryClient(&RetryPolicy{})
+	c.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if len(bodies) < 2 {
+			return nil, dial
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	ts, _ := flakyServer(0, http.StatusOK, "")
+	defer ts.Close()
+
+	ast.Nil(c.HTTPPost(ts.URL, map[string]string{"param": "code"}, NonIdempotent()))
+	ast.Equal([]string{"param=code", "param=code"}, bodies)
+	ret, err := c.GetResponseByte()
+	ast.Nil(err)
+	ast.Equal(`{"errcode":0,"param":"code"}`, string(ret))
+}
+
+// TestRetryContext
+func TestRetryContext(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var count int32
+	c := retryClient(&RetryPolicy{})
+	c.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&count, 1)
+		if err := r.Context().Err(); err != nil {
+			return nil, err
+		}
+		return nil, x509.UnknownAuthorityError{}
+	})
+
+	// the certificate is rejected
+	err := c.HTTPGet("https://domain.com", nil)
+	ast.Error(err)
+	ast.True(isCertificateError(err))
+	ast.Equal(int32(1), atomic.LoadInt32(&count))
+
+	// canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	atomic.StoreInt32(&count, 0)
+	err = c.HTTPGet("https://domain.com", nil, WithContext(ctx))
+	ast.True(errors.Is(err, context.Canceled), err)
+	ast.Equal(int32(1), atomic.LoadInt32(&count))
+
+	// the wait ends with the context
+	ts, count2 := flakyServer(5, http.StatusServiceUnavailable, "busy")
+	defer ts.Close()
+
+	c = retryClient(&RetryPolicy{})
+	c.Retry.BaseDelay, c.Retry.MaxDelay = time.Minute, time.Minute
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = c.HTTPGet(ts.URL, nil, WithContext(ctx))
+	ast.True(errors.Is(err, context.DeadlineExceeded), err)
+	ast.True(IsTimeout(err))
+	ast.True(time.Since(start) < 5*time.Second)
+	ast.Equal(int32(1), atomic.LoadInt32(count2))
+}
+
+// TestRetryDelay
+func TestRetryDelay(t *testing.T) {
+
+	ast := assert.New(t)
+
+	p := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for i := 0; i < 20; i++ {
+		d := p.delay(1)
+		ast.True(d >= 50*time.Millisecond && d <= 100*time.Millisecond, d)
+		d = p.delay(3)
+		ast.True(d >= 200*time.Millisecond && d <= 400*time.Millisecond, d)
+		d = p.delay(10)
+		ast.True(d >= 500*time.Millisecond && d <= time.Second, d)
+	}
+	ast.Equal(1, (*RetryPolicy)(nil).maxAttempts())
+}
@@ -2,8 +2,13 @@ package utils
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
 	"net/url"
 	"sort"
+	"strings"
 )
 
 // SortByKey sort by key
@@ -40,3 +45,84 @@ func QuerySortByKeyStr2(params map[string]string) (str string) {
 	}
 	return q.Encode()
 }
+
+// OAuthEscape percent-encode as rfc3986 requires (oauth 1.0a), only unreserved characters are kept
+func OAuthEscape(s string) string {
+	var ret bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~' {
+			ret.WriteByte(c)
+		} else {
+			ret.WriteString(fmt.Sprintf("%%%02X", c))
+		}
+	}
+	return ret.String()
+}
+
+// OAuthSignatureBase signature base string of oauth 1.0a, params are encoded and sorted by key
+// @doc: https://developer.twitter.com/en/docs/authentication/oauth-1-0a/creating-a-signature
+func OAuthSignatureBase(method, baseURL string, params map[string]string) string {
+	encoded := make(map[string]string, len(params))
+	for k, v := range params {
+		encoded[OAuthEscape(k)] = OAuthEscape(v)
+	}
+
+	var query bytes.Buffer
+	for i, k := range SortByKey(encoded) {
+		if i > 0 {
+			query.WriteString("&")
+		}
+		query.WriteString(k)
+		query.WriteString("=")
+		query.WriteString(encoded[k])
+	}
+
+	return strings.ToUpper(method) + "&" + OAuthEscape(oauthBaseURL(baseURL)) + "&" + OAuthEscape(query.String())
+}
+
+// oauthBaseURL base string uri: lowercase scheme and host, no default port, no query
+func oauthBaseURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+	if (scheme == "http" && strings.HasSuffix(host, ":80")) || (scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return scheme + "://" + host + path
+}
+
+// OAuthHmacSha1Sign sign the signature base string with consumer secret and token secret
+func OAuthHmacSha1Sign(base, consumerSecret, tokenSecret string) string {
+	mac := hmac.New(sha1.New, []byte(OAuthEscape(consumerSecret)+"&"+OAuthEscape(tokenSecret)))
+	_, _ = mac.Write([]byte(base))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// DataCheckString key=value pairs sorted by key and joined by \n, the exclude key (e.g. hash) is skipped
+// @doc: https://core.telegram.org/widgets/login#checking-authorization
+func DataCheckString(m map[string]string, exclude string) string {
+	keys := SortByKey(m)
+	var ret bytes.Buffer
+
+	for _, k := range keys {
+		if k == exclude {
+			continue
+		}
+		if ret.Len() > 0 {
+			ret.WriteString("\n")
+		}
+		ret.WriteString(k)
+		ret.WriteString("=")
+		ret.WriteString(m[k])
+	}
+	return ret.String()
+}
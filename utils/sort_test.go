@@ -14,3 +14,41 @@ func TestQuerySortByKeyStr2(t *testing.T) {
 	ast := assert.New(t)
 	ast.Equal("key1=val1&key2=val2", QuerySortByKeyStr2(map[string]string{"key1": "val1", "key2": "val2"}))
 }
+
+func TestOAuthEscape(t *testing.T) {
+	ast := assert.New(t)
+	ast.Equal("Ladies%20%2B%20Gentlemen%21~-._%E2%98%83", OAuthEscape("Ladies + Gentlemen!~-._☃"))
+}
+
+// TestOAuthSign example of https://developer.twitter.com/en/docs/authentication/oauth-1-0a/creating-a-signature
+func TestOAuthSign(t *testing.T) {
+	ast := assert.New(t)
+
+	params := map[string]string{
+		"status":                 "Hello Ladies + Gentlemen, a signed OAuth request!",
+		"include_entities":       "true",
+		"oauth_consumer_key":     "xvz1evFS4wEEPTGEFPHBog",
+		"oauth_nonce":            "kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg",
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        "1318622958",
+		"oauth_token":            "370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb",
+		"oauth_version":          "1.0",
+	}
+
+	base := OAuthSignatureBase("post", "https://api.twitter.com/1.1/statuses/update.json", params)
+	ast.Equal("POST&https%3A%2F%2Fapi.twitter.com%2F1.1%2Fstatuses%2Fupdate.json&include_entities%3Dtrue%26oauth_consumer_key%3Dxvz1evFS4wEEPTGEFPHBog%26oauth_nonce%3DkYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg%26oauth_signature_method%3DHMAC-SHA1%26oauth_timestamp%3D1318622958%26oauth_token%3D370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb%26oauth_version%3D1.0%26status%3DHello%2520Ladies%2520%252B%2520Gentlemen%252C%2520a%2520signed%2520OAuth%2520request%2521", base)
+
+	sign := OAuthHmacSha1Sign(base, "kAcSOqF21Fu85e7zjz7ZN2U4ZRhfV3WpwPAoE3Z7kBw", "LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE")
+	ast.Equal("hCtSmYh+iHYCEqBWrE7C7hYmtUk=", sign)
+}
+
+func TestOAuthBaseURL(t *testing.T) {
+	ast := assert.New(t)
+	ast.Equal("http://example.com/r%20v/X", oauthBaseURL("HTTP://Example.com:80/r%20v/X?id=123"))
+	ast.Equal("https://www.example.net:8080/", oauthBaseURL("https://www.example.net:8080"))
+}
+
+func TestDataCheckString(t *testing.T) {
+	ast := assert.New(t)
+	ast.Equal("auth_date=1\nid=2\nusername=u", DataCheckString(map[string]string{"username": "u", "hash": "h", "id": "2", "auth_date": "1"}, "hash"))
+}
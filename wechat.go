@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/birjemin/socialite/utils"
+	"strings"
 )
 
 const (
@@ -18,14 +19,62 @@ const (
 	wxGrantTypeRefresh = "refresh_token"
 
 	wxUserInfoURL = "https://api.weixin.qq.com/sns/userinfo"
+
+	wxAuthURL = "https://api.weixin.qq.com/sns/auth"
+)
+
+const (
+	// access_token is invalid
+	wxErrInvalidToken = 40001
+	// openid is invalid
+	wxErrInvalidOpenID = 40003
+	// access_token is expired
+	wxErrTokenExpired = 42001
+)
+
+const (
+	// WxLangZhCN simplified chinese
+	WxLangZhCN = "zh_CN"
+	// WxLangZhTW traditional chinese
+	WxLangZhTW = "zh_TW"
+	// WxLangEn english
+	WxLangEn = "en"
 )
 
+// WxGender gender of user info
+type WxGender int
+
+const (
+	// WxGenderUnknown unknown
+	WxGenderUnknown WxGender = iota
+	// WxGenderMale male
+	WxGenderMale
+	// WxGenderFemale female
+	WxGenderFemale
+)
+
+// String string of gender
+func (g WxGender) String() string {
+	switch g {
+	case WxGenderMale:
+		return "male"
+	case WxGenderFemale:
+		return "female"
+	default:
+		return "unknown"
+	}
+}
+
 // Wechat struct
 // @doc: https://developers.weixin.qq.com/doc/oplatform/Website_App/WeChat_Login/Wechat_Login.html
 type Wechat struct {
 	AppID       string
 	AppSecret   string
 	RedirectURL string
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	// Lang language of user info: zh_CN, zh_TW or en (default: zh_CN)
+	Lang        string
 	HTTPRequest *utils.HTTPClient
 }
 
@@ -46,38 +95,146 @@ type WxRespToken struct {
 	UnionID      string `json:"unionid"`
 }
 
+// Scopes granted scopes, e.g. snsapi_base,snsapi_userinfo
+func (t *WxRespToken) Scopes() []Scope {
+	return ParseScopes(t.Scope)
+}
+
 // WxUserInfo user info
 type WxUserInfo struct {
 	wxRespErrorToken
-	OpenID     string      `json:"openid"`
-	Nickname   string      `json:"nickname"`
-	Sex        int         `json:"sex"`
-	Province   string      `json:"province"`
-	City       string      `json:"city"`
-	Country    string      `json:"country"`
-	HeadImgURL string      `json:"headimgurl"`
-	Privilege  interface{} `json:"privilege"`
-	UnionID    string      `json:"unionid"`
-}
-
-// GetAuthorizeURL get authorize url
+	OpenID     string   `json:"openid"`
+	Nickname   string   `json:"nickname"`
+	Sex        int      `json:"sex"`
+	Province   string   `json:"province"`
+	City       string   `json:"city"`
+	Country    string   `json:"country"`
+	HeadImgURL string   `json:"headimgurl"`
+	Privilege  []string `json:"privilege"`
+	UnionID    string   `json:"unionid"`
+}
+
+// Gender gender of sex: 1 male, 2 female
+func (u *WxUserInfo) Gender() WxGender {
+	switch u.Sex {
+	case 1:
+		return WxGenderMale
+	case 2:
+		return WxGenderFemale
+	default:
+		return WxGenderUnknown
+	}
+}
+
+// HeadImgURLWithSize head image of the size: 0 (640*640), 46, 64, 96 or 132
+func (u *WxUserInfo) HeadImgURLWithSize(size int) (string, error) {
+
+	switch size {
+	case 0, 46, 64, 96, 132:
+	default:
+		return "", errors.New("size is invalid, must be 0, 46, 64, 96 or 132")
+	}
+
+	index := strings.LastIndex(u.HeadImgURL, "/")
+	if index < 0 {
+		return u.HeadImgURL, nil
+	}
+	return fmt.Sprintf("%s/%d", u.HeadImgURL[:index], size), nil
+}
+
+// WechatAuthorizeOptions options of the authorize url, shared by Wechat and WechatOfficialAccount
+type WechatAuthorizeOptions struct {
+	State string
+	// Scope snsapi_login for the website, snsapi_base or snsapi_userinfo for the official account
+	Scope string
+	// Lang cn or en, website only (default: cn)
+	Lang string
+	// ForcePopup official account only, see WechatOfficialAccount.ForcePopup
+	ForcePopup bool
+	// RedirectURL overrides Wechat.RedirectURL
+	RedirectURL string
+}
+
+// GetAuthorizeURL get authorize url, args: state
 func (w *Wechat) GetAuthorizeURL(args ...string) string {
 
-	params := make(map[string]string, 5)
+	params := make(map[string]string, 1)
+	if len(args) > 0 {
+		params["state"] = args[0]
+	}
+
+	return w.authorizeURL(w.RedirectURL, params)
+}
+
+// AuthorizeURLWithOptions get authorize url of the qr connect
+func (w *Wechat) AuthorizeURLWithOptions(opts WechatAuthorizeOptions) (string, error) {
+
+	if err := checkRedirectURL(opts.RedirectURL, w.RedirectURL, w.RedirectAllowlist); err != nil {
+		return "", err
+	}
+
+	if opts.Scope != "" && opts.Scope != wxScope {
+		return "", fmt.Errorf("scope %q is invalid", opts.Scope)
+	}
+	if opts.Lang != "" && opts.Lang != "cn" && opts.Lang != "en" {
+		return "", fmt.Errorf("lang %q is invalid", opts.Lang)
+	}
+	if opts.ForcePopup {
+		return "", errors.New("forcePopup is only supported by the official account")
+	}
+
+	redirectURL := w.RedirectURL
+	if opts.RedirectURL != "" {
+		redirectURL = opts.RedirectURL
+	}
+
+	return w.authorizeURL(redirectURL, map[string]string{
+		"state": opts.State,
+		"lang":  opts.Lang,
+	}), nil
+}
+
+// authorizeURL build the authorize url without validation, empty params are omitted
+func (w *Wechat) authorizeURL(redirectURL string, optional map[string]string) string {
+
+	params := make(map[string]string, 6)
 	params["appid"] = w.AppID
 	params["response_type"] = wxResponseType
-	params["redirect_uri"] = w.RedirectURL
+	params["redirect_uri"] = redirectURL
 	params["scope"] = wxScope
 
-	length := len(args)
-
-	if length > 0 {
-		params["state"] = args[0]
+	for k, v := range optional {
+		if v != "" {
+			params[k] = v
+		}
 	}
 
 	return fmt.Sprintf("%s?%s", wxAuthorizeURL, utils.QuerySortByKeyStr2(params))
 }
 
+// AuthorizeURL get authorize url, extra e.g. lang
+func (w *Wechat) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authState, authScopes, authRedirectURL, authGranted); err != nil {
+		return "", err
+	}
+	scope, err := o.joinScopes(WechatScopeCatalog)
+	if err != nil {
+		return "", err
+	}
+
+	ret, err := w.AuthorizeURLWithOptions(WechatAuthorizeOptions{
+		State:       o.State,
+		Scope:       scope,
+		RedirectURL: o.RedirectURL,
+	})
+	if err != nil {
+		return "", err
+	}
+	return o.withExtra(ret)
+}
+
 // Token get token
 func (w *Wechat) Token(code string) (interface{}, error) {
 
@@ -94,12 +251,12 @@ func (w *Wechat) doToken(url, code string) (ret *WxRespToken, err error) {
 		"code":       code,
 	}
 
-	if err := w.HTTPRequest.HTTPGet(url, params); err != nil {
+	if err := w.HTTPRequest.HTTPGet(url, params, utils.NonIdempotent()); err != nil {
 		return nil, err
 	}
 
 	ret = new(WxRespToken)
-	if w.HTTPRequest.GetResponseJSON(ret) != nil {
+	if err = w.HTTPRequest.GetResponseJSON(ret); err != nil {
 		return nil, err
 	}
 	return ret, nil
@@ -125,7 +282,7 @@ func (w *Wechat) doRefreshToken(url, refreshToken string) (ret *WxRespToken, err
 	}
 
 	ret = new(WxRespToken)
-	if w.HTTPRequest.GetResponseJSON(ret) != nil {
+	if err = w.HTTPRequest.GetResponseJSON(ret); err != nil {
 		return nil, err
 	}
 	return ret, nil
@@ -142,14 +299,32 @@ func (w *Wechat) GetUserInfo(accessToken, openID string) (interface{}, error) {
 	return w.doGetUserInfo(wxUserInfoURL, accessToken, openID)
 }
 
+// GetUserInfoWithLang get user info in the language: zh_CN, zh_TW or en
+func (w *Wechat) GetUserInfoWithLang(accessToken, openID, lang string) (*WxUserInfo, error) {
+	return w.doGetUserInfoWithLang(wxUserInfoURL, accessToken, openID, lang)
+}
+
 // doGetUserInfo handle
 func (w *Wechat) doGetUserInfo(url, accessToken, openID string) (*WxUserInfo, error) {
+	return w.doGetUserInfoWithLang(url, accessToken, openID, w.Lang)
+}
+
+// doGetUserInfoWithLang handle
+func (w *Wechat) doGetUserInfoWithLang(url, accessToken, openID, lang string) (*WxUserInfo, error) {
 
 	params := map[string]string{
 		"access_token": accessToken,
 		"openid":       openID,
 	}
 
+	switch lang {
+	case "":
+	case WxLangZhCN, WxLangZhTW, WxLangEn:
+		params["lang"] = lang
+	default:
+		return nil, errors.New("lang is invalid, must be zh_CN, zh_TW or en")
+	}
+
 	if err := w.HTTPRequest.HTTPGet(url, params); err != nil {
 		return nil, err
 	}
@@ -160,3 +335,113 @@ func (w *Wechat) doGetUserInfo(url, accessToken, openID string) (*WxUserInfo, er
 	}
 	return ret, nil
 }
+
+// ValidateToken check whether the access token is still valid for the openid
+// @doc: https://developers.weixin.qq.com/doc/oplatform/Website_App/WeChat_Login/Authorized_Interface_Calling_UnionID.html
+func (w *Wechat) ValidateToken(accessToken, openID string) (bool, error) {
+	return w.doValidateToken(wxAuthURL, accessToken, openID)
+}
+
+// doValidateToken handle
+func (w *Wechat) doValidateToken(url, accessToken, openID string) (bool, error) {
+
+	params := map[string]string{
+		"access_token": accessToken,
+		"openid":       openID,
+	}
+
+	if err := w.HTTPRequest.HTTPGet(url, params); err != nil {
+		return false, err
+	}
+
+	ret := new(wxRespErrorToken)
+	if err := w.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return false, err
+	}
+
+	switch ret.ErrCode {
+	case 0:
+		return true, nil
+	case wxErrInvalidToken, wxErrInvalidOpenID, wxErrTokenExpired:
+		return false, nil
+	default:
+		return false, fmt.Errorf("validate token error: %d %s", ret.ErrCode, ret.ErrMsg)
+	}
+}
+
+// EnsureToken validate the token and refresh it when it is expired, the token is updated in place
+func (w *Wechat) EnsureToken(token *WxRespToken) (refreshed bool, err error) {
+	return w.doEnsureToken(wxAuthURL, wxRefreshTokenURL, token)
+}
+
+// doEnsureToken handle
+func (w *Wechat) doEnsureToken(authURL, refreshURL string, token *WxRespToken) (bool, error) {
+
+	valid, err := w.doValidateToken(authURL, token.AccessToken, token.OpenID)
+	if err != nil {
+		return false, err
+	}
+	if valid {
+		return false, nil
+	}
+
+	if err := w.refresh(refreshURL, token); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// refresh refresh the token in place
+func (w *Wechat) refresh(url string, token *WxRespToken) error {
+
+	if token.RefreshToken == "" {
+		return errors.New("refresh token is empty, re-authorize")
+	}
+
+	ret, err := w.doRefreshToken(url, token.RefreshToken)
+	if err != nil {
+		return err
+	}
+	if ret == nil {
+		return errors.New("refresh token response is invalid")
+	}
+	if ret.ErrCode != 0 {
+		return fmt.Errorf("refresh token error, re-authorize: %d %s", ret.ErrCode, ret.ErrMsg)
+	}
+
+	// unionid is not returned by refresh_token
+	unionID := token.UnionID
+	*token = *ret
+	if token.UnionID == "" {
+		token.UnionID = unionID
+	}
+	return nil
+}
+
+// GetUserInfoByToken get user info, an expired token is refreshed (in place) instead of failing
+func (w *Wechat) GetUserInfoByToken(token *WxRespToken) (*WxUserInfo, error) {
+	return w.doGetUserInfoByToken(wxAuthURL, wxRefreshTokenURL, wxUserInfoURL, token)
+}
+
+// doGetUserInfoByToken handle
+func (w *Wechat) doGetUserInfoByToken(authURL, refreshURL, userInfoURL string, token *WxRespToken) (*WxUserInfo, error) {
+
+	refreshed, err := w.doEnsureToken(authURL, refreshURL, token)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := w.doGetUserInfo(userInfoURL, token.AccessToken, token.OpenID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the token may expire between sns/auth and sns/userinfo
+	if ret.ErrCode == wxErrTokenExpired && !refreshed {
+		if err := w.refresh(refreshURL, token); err != nil {
+			return nil, err
+		}
+		return w.doGetUserInfo(userInfoURL, token.AccessToken, token.OpenID)
+	}
+	return ret, nil
+}
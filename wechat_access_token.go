@@ -0,0 +1,314 @@
+package socialite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	jsoniter "github.com/json-iterator/go"
+	"sync"
+	"time"
+)
+
+const (
+	wxCgiTokenURL     = "https://api.weixin.qq.com/cgi-bin/token"
+	wxStableTokenURL  = "https://api.weixin.qq.com/cgi-bin/stable_token"
+	wxGrantTypeClient = "client_credential"
+
+	// default duration to refresh the token ahead of its expiry
+	wxTokenRefreshAhead = 5 * time.Minute
+)
+
+// WxAccessToken response of cgi-bin/token and cgi-bin/stable_token
+type WxAccessToken struct {
+	wxRespErrorToken
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// WxTokenCache cache of app-level access tokens, share it (e.g. redis) between instances
+// so that they do not fetch and invalidate each other's tokens
+type WxTokenCache interface {
+	// Get an empty token is returned when it is missing
+	Get(key string) (token string, expireAt time.Time, err error)
+	Set(key, token string, expireAt time.Time) error
+}
+
+// WxTokenLocker lock across the instances (e.g. redis SET NX), the Cache must implement it for
+// cgi-bin/token so that only one instance fetches the token and the others reuse it
+type WxTokenLocker interface {
+	// Lock acquire the lock of the key, wait until the other instance releases it
+	Lock(key string) (unlock func() error, err error)
+}
+
+// WxMemoryTokenCache in-memory WxTokenCache and WxTokenLocker, only suitable for a single instance
+type WxMemoryTokenCache struct {
+	mu     sync.RWMutex
+	tokens map[string]wxCachedToken
+	locks  map[string]*sync.Mutex
+}
+
+// wxCachedToken cached token
+type wxCachedToken struct {
+	token    string
+	expireAt time.Time
+}
+
+// Get get token
+func (c *WxMemoryTokenCache) Get(key string) (string, time.Time, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	item := c.tokens[key]
+	return item.token, item.expireAt, nil
+}
+
+// Set set token
+func (c *WxMemoryTokenCache) Set(key, token string, expireAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tokens == nil {
+		c.tokens = make(map[string]wxCachedToken)
+	}
+	c.tokens[key] = wxCachedToken{token: token, expireAt: expireAt}
+	return nil
+}
+
+// Lock lock of the key
+func (c *WxMemoryTokenCache) Lock(key string) (func() error, error) {
+	c.mu.Lock()
+	if c.locks == nil {
+		c.locks = make(map[string]*sync.Mutex)
+	}
+	l, ok := c.locks[key]
+	if !ok {
+		l = new(sync.Mutex)
+		c.locks[key] = l
+	}
+	c.mu.Unlock()
+
+	l.Lock()
+	return func() error {
+		l.Unlock()
+		return nil
+	}, nil
+}
+
+// WxAccessTokenManager manager of the app-level access_token (e.g. for cgi-bin/user/info, template messages)
+// the token is cached, refreshed ahead of its expiry, and concurrent fetches are deduplicated
+// @doc: https://developers.weixin.qq.com/doc/offiaccount/Basic_Information/Get_access_token.html
+// @doc: https://developers.weixin.qq.com/doc/offiaccount/Basic_Information/getStableAccessToken.html
+type WxAccessTokenManager struct {
+	AppID     string
+	AppSecret string
+	// Legacy use cgi-bin/token instead of cgi-bin/stable_token, each fetch invalidates the previous token,
+	// a shared Cache must implement WxTokenLocker
+	Legacy bool
+	// Cache shared cache (default: in-memory)
+	Cache WxTokenCache
+	// RefreshAhead refresh the token when it expires within this duration (default: 5m)
+	RefreshAhead time.Duration
+	HTTPRequest  *utils.HTTPClient
+
+	mu     sync.Mutex
+	call   *wxTokenCall
+	memory WxMemoryTokenCache
+}
+
+// wxTokenCall an in-flight fetch
+type wxTokenCall struct {
+	wg    sync.WaitGroup
+	token string
+	err   error
+}
+
+// cache cache of the manager
+func (m *WxAccessTokenManager) cache() WxTokenCache {
+	if m.Cache != nil {
+		return m.Cache
+	}
+	return &m.memory
+}
+
+// cacheKey cache key of the app
+func (m *WxAccessTokenManager) cacheKey() string {
+	if m.Legacy {
+		return "wechat:access_token:" + m.AppID
+	}
+	return "wechat:stable_access_token:" + m.AppID
+}
+
+// tokenURL url of the api
+func (m *WxAccessTokenManager) tokenURL() string {
+	if m.Legacy {
+		return wxCgiTokenURL
+	}
+	return wxStableTokenURL
+}
+
+// refreshAhead refresh ahead duration
+func (m *WxAccessTokenManager) refreshAhead() time.Duration {
+	if m.RefreshAhead > 0 {
+		return m.RefreshAhead
+	}
+	return wxTokenRefreshAhead
+}
+
+// cached get the cached token if it is still fresh
+func (m *WxAccessTokenManager) cached() (string, error) {
+	token, expireAt, err := m.cache().Get(m.cacheKey())
+	if err != nil {
+		return "", err
+	}
+	if token != "" && time.Until(expireAt) > m.refreshAhead() {
+		return token, nil
+	}
+	return "", nil
+}
+
+// Token get the access token, from cache if it is fresh
+func (m *WxAccessTokenManager) Token() (string, error) {
+	return m.doGetToken(m.tokenURL())
+}
+
+// doGetToken handle
+func (m *WxAccessTokenManager) doGetToken(url string) (string, error) {
+	token, err := m.cached()
+	if err != nil || token != "" {
+		return token, err
+	}
+	return m.fetch(url, false)
+}
+
+// ForceRefresh fetch a new token regardless of the cache, the apis are rate-limited, use it sparingly
+func (m *WxAccessTokenManager) ForceRefresh() (string, error) {
+	return m.doForceRefresh(m.tokenURL())
+}
+
+// doForceRefresh handle
+func (m *WxAccessTokenManager) doForceRefresh(url string) (string, error) {
+	return m.fetch(url, true)
+}
+
+// Invalidate drop the cached token if it is the given one (e.g. the api returns 40001 for it)
+func (m *WxAccessTokenManager) Invalidate(token string) error {
+	cached, _, err := m.cache().Get(m.cacheKey())
+	if err != nil || cached != token {
+		return err
+	}
+	return m.cache().Set(m.cacheKey(), "", time.Time{})
+}
+
+// fetch deduplicate concurrent fetches
+func (m *WxAccessTokenManager) fetch(url string, force bool) (string, error) {
+
+	m.mu.Lock()
+	if c := m.call; c != nil {
+		m.mu.Unlock()
+		c.wg.Wait()
+		return c.token, c.err
+	}
+	c := new(wxTokenCall)
+	c.wg.Add(1)
+	m.call = c
+	m.mu.Unlock()
+
+	c.token, c.err = m.doFetch(url, force)
+
+	m.mu.Lock()
+	m.call = nil
+	m.mu.Unlock()
+	c.wg.Done()
+
+	return c.token, c.err
+}
+
+// doFetch fetch the token and cache it, cgi-bin/token is fetched under the lock of the cache
+func (m *WxAccessTokenManager) doFetch(url string, force bool) (string, error) {
+
+	if m.Legacy {
+		locker, ok := m.cache().(WxTokenLocker)
+		if !ok {
+			return "", errors.New("the cache must implement WxTokenLocker for cgi-bin/token")
+		}
+		unlock, err := locker.Lock(m.cacheKey())
+		if err != nil {
+			return "", err
+		}
+		defer unlock()
+	}
+
+	// another instance may have refreshed it
+	if !force {
+		if token, err := m.cached(); err != nil || token != "" {
+			return token, err
+		}
+	}
+
+	var (
+		ret *WxAccessToken
+		err error
+	)
+	if m.Legacy {
+		ret, err = m.doToken(url)
+	} else {
+		ret, err = m.doStableToken(url, force)
+	}
+	if err != nil {
+		return "", err
+	}
+	if ret.ErrCode != 0 {
+		return "", fmt.Errorf("get access token error: %d %s", ret.ErrCode, ret.ErrMsg)
+	}
+	if ret.AccessToken == "" {
+		return "", errors.New("access token is empty")
+	}
+
+	expireAt := time.Now().Add(time.Duration(ret.ExpiresIn) * time.Second)
+	if err := m.cache().Set(m.cacheKey(), ret.AccessToken, expireAt); err != nil {
+		return "", err
+	}
+	return ret.AccessToken, nil
+}
+
+// doToken handle cgi-bin/token
+func (m *WxAccessTokenManager) doToken(url string) (*WxAccessToken, error) {
+
+	params := map[string]string{
+		"grant_type": wxGrantTypeClient,
+		"appid":      m.AppID,
+		"secret":     m.AppSecret,
+	}
+
+	if err := m.HTTPRequest.HTTPGet(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(WxAccessToken)
+	if err := m.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// doStableToken handle cgi-bin/stable_token
+func (m *WxAccessTokenManager) doStableToken(url string, force bool) (*WxAccessToken, error) {
+
+	body, err := jsoniter.MarshalToString(struct {
+		GrantType    string `json:"grant_type"`
+		AppID        string `json:"appid"`
+		Secret       string `json:"secret"`
+		ForceRefresh bool   `json:"force_refresh"`
+	}{wxGrantTypeClient, m.AppID, m.AppSecret, force})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.HTTPRequest.HTTPPostJSON(url, body); err != nil {
+		return nil, err
+	}
+
+	ret := new(WxAccessToken)
+	if err := m.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
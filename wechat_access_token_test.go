@@ -0,0 +1,175 @@
+package socialite
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// wxTokenServer fake server of cgi-bin/token and cgi-bin/stable_token
+func wxTokenServer(t *testing.T, hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		n := atomic.AddInt32(hits, 1)
+		time.Sleep(20 * time.Millisecond)
+
+		appID, secret, force := r.FormValue("appid"), r.FormValue("secret"), false
+		if r.Method == http.MethodPost {
+			body := struct {
+				GrantType    string `json:"grant_type"`
+				AppID        string `json:"appid"`
+				Secret       string `json:"secret"`
+				ForceRefresh bool   `json:"force_refresh"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			appID, secret, force = body.AppID, body.Secret, body.ForceRefresh
+		}
+
+		ret := fmt.Sprintf(`{"access_token":"ACCESS_TOKEN_%d_%v","expires_in":7200}`, n, force)
+		if appID != "APPID" || secret != "SECRET" {
+			ret = `{"errcode":40125,"errmsg":"invalid appsecret"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+}
+
+// TestWxAccessTokenManager
+func TestWxAccessTokenManager(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var hits int32
+	ts := wxTokenServer(t, &hits)
+	defer ts.Close()
+
+	m := &WxAccessTokenManager{AppID: "APPID", AppSecret: "SECRET", Legacy: true, HTTPRequest: wxHTTPClient}
+
+	// concurrent fetches are deduplicated
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], _ = m.doGetToken(ts.URL)
+		}(i)
+	}
+	wg.Wait()
+
+	ast.Equal(int32(1), atomic.LoadInt32(&hits))
+	for _, token := range tokens {
+		ast.Equal("ACCESS_TOKEN_1_false", token)
+	}
+
+	// cached
+	token, err := m.doGetToken(ts.URL)
+	ast.Nil(err)
+	ast.Equal("ACCESS_TOKEN_1_false", token)
+	ast.Equal(int32(1), atomic.LoadInt32(&hits))
+
+	// invalidated
+	ast.Nil(m.Invalidate("OTHER_TOKEN"))
+	token, _ = m.doGetToken(ts.URL)
+	ast.Equal("ACCESS_TOKEN_1_false", token)
+	ast.Nil(m.Invalidate("ACCESS_TOKEN_1_false"))
+	token, _ = m.doGetToken(ts.URL)
+	ast.Equal("ACCESS_TOKEN_2_false", token)
+
+	// fail
+	m = &WxAccessTokenManager{AppID: "APPID", AppSecret: "WRONG", HTTPRequest: wxHTTPClient}
+	_, err = m.doGetToken(ts.URL)
+	ast.EqualError(err, "get access token error: 40125 invalid appsecret")
+}
+
+// TestWxAccessTokenManagerSharedCache
+func TestWxAccessTokenManagerSharedCache(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var hits int32
+	ts := wxTokenServer(t, &hits)
+	defer ts.Close()
+
+	cache := &WxMemoryTokenCache{}
+	m1 := &WxAccessTokenManager{AppID: "APPID", AppSecret: "SECRET", Cache: cache, HTTPRequest: wxHTTPClient}
+	m2 := &WxAccessTokenManager{AppID: "APPID", AppSecret: "SECRET", Cache: cache, HTTPRequest: wxHTTPClient}
+
+	token, err := m1.doGetToken(ts.URL)
+	ast.Nil(err)
+	ast.Equal("ACCESS_TOKEN_1_false", token)
+
+	// the other instance reuses the shared token
+	token, err = m2.doGetToken(ts.URL)
+	ast.Nil(err)
+	ast.Equal("ACCESS_TOKEN_1_false", token)
+	ast.Equal(int32(1), atomic.LoadInt32(&hits))
+
+	// refreshed ahead of expiry
+	ast.Nil(cache.Set(m1.cacheKey(), "ACCESS_TOKEN_1_false", time.Now().Add(time.Minute)))
+	token, _ = m2.doGetToken(ts.URL)
+	ast.Equal("ACCESS_TOKEN_2_false", token)
+
+	// force refresh
+	token, err = m1.doForceRefresh(ts.URL)
+	ast.Nil(err)
+	ast.Equal("ACCESS_TOKEN_3_true", token)
+	token, _ = m2.doGetToken(ts.URL)
+	ast.Equal("ACCESS_TOKEN_3_true", token)
+}
+
+// wxPlainTokenCache shared cache without a lock
+type wxPlainTokenCache struct {
+	WxTokenCache
+}
+
+// TestWxAccessTokenManagerLegacy
+func TestWxAccessTokenManagerLegacy(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var hits int32
+	ts := wxTokenServer(t, &hits)
+	defer ts.Close()
+
+	// instances sharing the cache fetch cgi-bin/token once
+	cache := &WxMemoryTokenCache{}
+	managers := []*WxAccessTokenManager{
+		{AppID: "APPID", AppSecret: "SECRET", Legacy: true, Cache: cache, HTTPRequest: wxHTTPClient},
+		{AppID: "APPID", AppSecret: "SECRET", Legacy: true, Cache: cache, HTTPRequest: wxHTTPClient},
+		{AppID: "APPID", AppSecret: "SECRET", Legacy: true, Cache: cache, HTTPRequest: wxHTTPClient},
+	}
+
+	var wg sync.WaitGroup
+	tokens := make([]string, len(managers))
+	for i, m := range managers {
+		wg.Add(1)
+		go func(i int, m *WxAccessTokenManager) {
+			defer wg.Done()
+			tokens[i], _ = m.doGetToken(ts.URL)
+		}(i, m)
+	}
+	wg.Wait()
+
+	ast.Equal(int32(1), atomic.LoadInt32(&hits))
+	for _, token := range tokens {
+		ast.Equal("ACCESS_TOKEN_1_false", token)
+	}
+
+	// a shared cache without a lock
+	m := &WxAccessTokenManager{AppID: "APPID", AppSecret: "SECRET", Legacy: true, Cache: &wxPlainTokenCache{&WxMemoryTokenCache{}}, HTTPRequest: wxHTTPClient}
+	_, err := m.doGetToken(ts.URL)
+	ast.EqualError(err, "the cache must implement WxTokenLocker for cgi-bin/token")
+	ast.Equal(int32(1), atomic.LoadInt32(&hits))
+}
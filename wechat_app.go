@@ -0,0 +1,208 @@
+package socialite
+
+import (
+	"errors"
+	"github.com/birjemin/socialite/utils"
+	"sync"
+)
+
+// WechatApp login of mobile application, the app sends the code issued by the wechat sdk to the backend
+// the app has its own AppID/AppSecret, openids differ from the website and official account apps,
+// unionid is the same for all apps bound to one open platform account
+// @doc: https://developers.weixin.qq.com/doc/oplatform/Mobile_App/WeChat_Login/Development_Guide.html
+type WechatApp struct {
+	AppID     string
+	AppSecret string
+	// Lang language of user info: zh_CN, zh_TW or en (default: zh_CN)
+	Lang        string
+	HTTPRequest *utils.HTTPClient
+}
+
+// client the endpoints are shared with the website app
+func (w *WechatApp) client() *Wechat {
+	return &Wechat{
+		AppID:       w.AppID,
+		AppSecret:   w.AppSecret,
+		Lang:        w.Lang,
+		HTTPRequest: w.HTTPRequest,
+	}
+}
+
+// GetAuthorizeURL the authorization is started by the wechat sdk in the app
+func (w *WechatApp) GetAuthorizeURL(args ...string) string {
+	return "invalid"
+}
+
+// AuthorizeURL the authorization is started by the wechat sdk in the app
+func (w *WechatApp) AuthorizeURL(opts ...AuthOption) (string, error) {
+	return "", errors.New("can not support")
+}
+
+// Token get token
+func (w *WechatApp) Token(code string) (interface{}, error) {
+	return w.client().Token(code)
+}
+
+// RefreshToken refresh token
+func (w *WechatApp) RefreshToken(refreshToken string) (interface{}, error) {
+	return w.client().RefreshToken(refreshToken)
+}
+
+// GetMe get me
+func (w *WechatApp) GetMe(accessToken string) (interface{}, error) {
+	return nil, errors.New("can not support")
+}
+
+// GetUserInfo get user info
+func (w *WechatApp) GetUserInfo(accessToken, openID string) (interface{}, error) {
+	return w.client().GetUserInfo(accessToken, openID)
+}
+
+// ValidateToken check whether the access token is still valid for the openid
+func (w *WechatApp) ValidateToken(accessToken, openID string) (bool, error) {
+	return w.client().ValidateToken(accessToken, openID)
+}
+
+// GetUserInfoByToken get user info, an expired token is refreshed (in place) instead of failing
+func (w *WechatApp) GetUserInfoByToken(token *WxRespToken) (*WxUserInfo, error) {
+	return w.client().GetUserInfoByToken(token)
+}
+
+// WxIdentity one person across the website, mobile and official account apps
+type WxIdentity struct {
+	UnionID string
+	// OpenIDs openid of each appid
+	OpenIDs map[string]string
+}
+
+// WxIdentityStore store of the identities, must be shared by all instances
+type WxIdentityStore interface {
+	// Link link the openid of the app to the unionid, the previous links of the openid
+	// and of the app on the identity are replaced
+	Link(appID, openID, unionID string) error
+	// Get identity by unionid, nil when it is not found
+	Get(unionID string) (*WxIdentity, error)
+	// UnionID unionid of the openid of the app, "" when it is not found
+	UnionID(appID, openID string) (string, error)
+}
+
+// WxMemoryIdentityStore in-memory WxIdentityStore, only suitable for a single instance
+type WxMemoryIdentityStore struct {
+	mu         sync.RWMutex
+	identities map[string]*WxIdentity
+	// appid + openid => unionid
+	unionIDs map[string]string
+}
+
+// Link link the openid of the app to the unionid
+func (s *WxMemoryIdentityStore) Link(appID, openID, unionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identities == nil {
+		s.identities = make(map[string]*WxIdentity)
+		s.unionIDs = make(map[string]string)
+	}
+
+	// the openid was linked to another unionid
+	key := appID + "|" + openID
+	if old, ok := s.unionIDs[key]; ok && old != unionID {
+		if identity, ok := s.identities[old]; ok && identity.OpenIDs[appID] == openID {
+			delete(identity.OpenIDs, appID)
+		}
+	}
+
+	identity, ok := s.identities[unionID]
+	if !ok {
+		identity = &WxIdentity{UnionID: unionID, OpenIDs: make(map[string]string)}
+		s.identities[unionID] = identity
+	}
+	// the app had another openid for the unionid
+	if old, ok := identity.OpenIDs[appID]; ok && old != openID {
+		delete(s.unionIDs, appID+"|"+old)
+	}
+	identity.OpenIDs[appID] = openID
+	s.unionIDs[key] = unionID
+	return nil
+}
+
+// Get identity by unionid
+func (s *WxMemoryIdentityStore) Get(unionID string) (*WxIdentity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	identity, ok := s.identities[unionID]
+	if !ok {
+		return nil, nil
+	}
+	return identity.copy(), nil
+}
+
+// UnionID unionid of the openid of the app
+func (s *WxMemoryIdentityStore) UnionID(appID, openID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.unionIDs[appID+"|"+openID], nil
+}
+
+// WxUnionIDResolver resolve the same person across apps by unionid
+type WxUnionIDResolver struct {
+	// Store keeps the identities, must be shared by all instances (default: in-memory)
+	Store WxIdentityStore
+
+	once sync.Once
+}
+
+// store the store, in-memory when Store is not set
+func (r *WxUnionIDResolver) store() WxIdentityStore {
+	r.once.Do(func() {
+		if r.Store == nil {
+			r.Store = new(WxMemoryIdentityStore)
+		}
+	})
+	return r.Store
+}
+
+// Link link the openid of the app to the unionid
+func (r *WxUnionIDResolver) Link(appID, openID, unionID string) (*WxIdentity, error) {
+
+	if unionID == "" {
+		return nil, errors.New("unionid is empty, the app is not bound to an open platform account")
+	}
+	if appID == "" || openID == "" {
+		return nil, errors.New("appid and openid are required")
+	}
+
+	if err := r.store().Link(appID, openID, unionID); err != nil {
+		return nil, err
+	}
+	return r.store().Get(unionID)
+}
+
+// LinkToken link the openid and unionid of the token
+func (r *WxUnionIDResolver) LinkToken(appID string, token *WxRespToken) (*WxIdentity, error) {
+	return r.Link(appID, token.OpenID, token.UnionID)
+}
+
+// ByUnionID find the identity by unionid, nil when it is not found
+func (r *WxUnionIDResolver) ByUnionID(unionID string) (*WxIdentity, error) {
+	return r.store().Get(unionID)
+}
+
+// ByOpenID find the identity by the openid of an app, nil when it is not found
+func (r *WxUnionIDResolver) ByOpenID(appID, openID string) (*WxIdentity, error) {
+	unionID, err := r.store().UnionID(appID, openID)
+	if err != nil || unionID == "" {
+		return nil, err
+	}
+	return r.store().Get(unionID)
+}
+
+// copy copy of the identity
+func (i *WxIdentity) copy() *WxIdentity {
+	ret := &WxIdentity{UnionID: i.UnionID, OpenIDs: make(map[string]string, len(i.OpenIDs))}
+	for k, v := range i.OpenIDs {
+		ret.OpenIDs[k] = v
+	}
+	return ret
+}
@@ -0,0 +1,125 @@
+package socialite
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var (
+	wxAppObj = &WechatApp{
+		AppID:       "MOBILE_APPID",
+		AppSecret:   "MOBILE_SECRET",
+		HTTPRequest: wxHTTPClient,
+	}
+)
+
+// TestWxAppToken
+func TestWxAppToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"access_token":"YOUR_ACCESS_TOKEN","expires_in":7200,"refresh_token":"YOUR_REFRESH_TOKEN","openid":"MOBILE_OPENID","scope":"snsapi_userinfo","unionid":"UNIONID"}`
+		if r.FormValue("appid") != "MOBILE_APPID" || r.FormValue("secret") != "MOBILE_SECRET" {
+			ret = `{"errcode":40125,"errmsg":"invalid appsecret"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// the mobile credentials are used
+	ret, err := wxAppObj.client().doToken(ts.URL, "code")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("MOBILE_OPENID", ret.OpenID)
+	ast.Equal("UNIONID", ret.UnionID)
+
+	// the website credentials are rejected
+	ret, err = wxObj.doToken(ts.URL, "code")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(40125, ret.ErrCode)
+
+	ast.Equal("invalid", wxAppObj.GetAuthorizeURL())
+}
+
+// TestWxUnionIDResolver
+func TestWxUnionIDResolver(t *testing.T) {
+
+	ast := assert.New(t)
+
+	resolver := &WxUnionIDResolver{}
+
+	_, err := resolver.LinkToken("WEB_APPID", &WxRespToken{OpenID: "WEB_OPENID", UnionID: "UNIONID"})
+	ast.Nil(err)
+	_, err = resolver.Link("MOBILE_APPID", "MOBILE_OPENID", "UNIONID")
+	ast.Nil(err)
+	_, err = resolver.Link("OA_APPID", "OA_OPENID", "OTHER_UNIONID")
+	ast.Nil(err)
+
+	// unionid is required
+	_, err = resolver.Link("OA_APPID", "OA_OPENID", "")
+	ast.Error(err)
+
+	identity, err := resolver.ByOpenID("MOBILE_APPID", "MOBILE_OPENID")
+	ast.Nil(err)
+	ast.Equal("UNIONID", identity.UnionID)
+	ast.Equal(map[string]string{"WEB_APPID": "WEB_OPENID", "MOBILE_APPID": "MOBILE_OPENID"}, identity.OpenIDs)
+
+	// the returned identity is a copy
+	identity.OpenIDs["FAKE"] = "FAKE"
+	identity, err = resolver.ByUnionID("UNIONID")
+	ast.Nil(err)
+	ast.Len(identity.OpenIDs, 2)
+
+	identity, err = resolver.ByOpenID("MOBILE_APPID", "WEB_OPENID")
+	ast.Nil(err)
+	ast.Nil(identity)
+
+	// re-link the openid to another unionid
+	_, err = resolver.Link("MOBILE_APPID", "MOBILE_OPENID", "OTHER_UNIONID")
+	ast.Nil(err)
+	identity, _ = resolver.ByUnionID("UNIONID")
+	ast.Equal(map[string]string{"WEB_APPID": "WEB_OPENID"}, identity.OpenIDs)
+	identity, _ = resolver.ByOpenID("MOBILE_APPID", "MOBILE_OPENID")
+	ast.Equal("OTHER_UNIONID", identity.UnionID)
+
+	// the app has a new openid for the unionid
+	_, err = resolver.Link("WEB_APPID", "NEW_WEB_OPENID", "UNIONID")
+	ast.Nil(err)
+	identity, _ = resolver.ByOpenID("WEB_APPID", "WEB_OPENID")
+	ast.Nil(identity)
+	identity, _ = resolver.ByUnionID("UNIONID")
+	ast.Equal(map[string]string{"WEB_APPID": "NEW_WEB_OPENID"}, identity.OpenIDs)
+}
+
+// TestWxUnionIDResolverStore
+func TestWxUnionIDResolverStore(t *testing.T) {
+
+	ast := assert.New(t)
+
+	// instances sharing the store
+	store := new(WxMemoryIdentityStore)
+	web, mobile := &WxUnionIDResolver{Store: store}, &WxUnionIDResolver{Store: store}
+
+	_, err := web.Link("WEB_APPID", "WEB_OPENID", "UNIONID")
+	ast.Nil(err)
+	_, err = mobile.Link("MOBILE_APPID", "MOBILE_OPENID", "UNIONID")
+	ast.Nil(err)
+
+	identity, err := web.ByOpenID("MOBILE_APPID", "MOBILE_OPENID")
+	ast.Nil(err)
+	ast.Equal(map[string]string{"WEB_APPID": "WEB_OPENID", "MOBILE_APPID": "MOBILE_OPENID"}, identity.OpenIDs)
+}
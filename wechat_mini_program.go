@@ -0,0 +1,99 @@
+package socialite
+
+import (
+	"github.com/birjemin/socialite/utils"
+	"time"
+)
+
+const (
+	wxCode2SessionURL = "https://api.weixin.qq.com/sns/jscode2session"
+)
+
+// WechatMiniProgram server-side login of mini program
+// @doc: https://developers.weixin.qq.com/miniprogram/dev/api-backend/open-api/login/auth.code2Session.html
+type WechatMiniProgram struct {
+	AppID     string
+	AppSecret string
+	// WatermarkMaxAge max age of the watermark timestamp of decrypted data (default: not checked)
+	WatermarkMaxAge time.Duration
+	HTTPRequest     *utils.HTTPClient
+}
+
+// WxRespSession response of code2Session
+type WxRespSession struct {
+	wxRespErrorToken
+	OpenID     string `json:"openid"`
+	SessionKey string `json:"session_key"`
+	UnionID    string `json:"unionid"`
+}
+
+// WxMiniWatermark watermark of decrypted data
+type WxMiniWatermark struct {
+	AppID     string `json:"appid"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// WxMiniPhoneNumber decrypted phone number
+type WxMiniPhoneNumber struct {
+	PhoneNumber     string          `json:"phoneNumber"`
+	PurePhoneNumber string          `json:"purePhoneNumber"`
+	CountryCode     string          `json:"countryCode"`
+	Watermark       WxMiniWatermark `json:"watermark"`
+}
+
+// WxMiniUserInfo decrypted user info
+type WxMiniUserInfo struct {
+	OpenID    string          `json:"openId"`
+	NickName  string          `json:"nickName"`
+	Gender    int             `json:"gender"`
+	Language  string          `json:"language"`
+	City      string          `json:"city"`
+	Province  string          `json:"province"`
+	Country   string          `json:"country"`
+	AvatarURL string          `json:"avatarUrl"`
+	UnionID   string          `json:"unionId"`
+	Watermark WxMiniWatermark `json:"watermark"`
+}
+
+// Code2Session exchange the code of wx.login for openid, unionid and session_key
+func (w *WechatMiniProgram) Code2Session(code string) (*WxRespSession, error) {
+	return w.doCode2Session(wxCode2SessionURL, code)
+}
+
+// doCode2Session handle
+func (w *WechatMiniProgram) doCode2Session(url, code string) (*WxRespSession, error) {
+
+	ret := new(WxRespSession)
+	if err := miniProgramCode2Session(w.HTTPRequest, url, w.AppID, w.AppSecret, code, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// VerifyRawData check the signature (sha1 of rawData + session_key) of getUserInfo
+func (w *WechatMiniProgram) VerifyRawData(rawData, signature, sessionKey string) bool {
+	return miniProgramVerifyRawData(rawData, signature, sessionKey)
+}
+
+// DecryptData decrypt encryptedData with iv and session_key into v, the watermark is checked
+func (w *WechatMiniProgram) DecryptData(sessionKey, encryptedData, iv string, v interface{}) error {
+	return miniProgramDecrypt(w.AppID, w.WatermarkMaxAge, sessionKey, encryptedData, iv, v)
+}
+
+// DecryptPhoneNumber decrypt the data of getPhoneNumber
+func (w *WechatMiniProgram) DecryptPhoneNumber(sessionKey, encryptedData, iv string) (*WxMiniPhoneNumber, error) {
+	ret := new(WxMiniPhoneNumber)
+	if err := w.DecryptData(sessionKey, encryptedData, iv, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// DecryptUserInfo decrypt the data of getUserInfo
+func (w *WechatMiniProgram) DecryptUserInfo(sessionKey, encryptedData, iv string) (*WxMiniUserInfo, error) {
+	ret := new(WxMiniUserInfo)
+	if err := w.DecryptData(sessionKey, encryptedData, iv, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
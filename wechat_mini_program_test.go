@@ -0,0 +1,124 @@
+package socialite
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	wxMiniObj = &WechatMiniProgram{
+		AppID:       "wx4f4bc4dec97d474b",
+		AppSecret:   "SECRET",
+		HTTPRequest: wxHTTPClient,
+	}
+)
+
+// TestWxMiniCode2Session
+func TestWxMiniCode2Session(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"openid":"OPENID","session_key":"SESSIONKEY","unionid":"UNIONID"}`
+		for _, v := range []string{"appid", "secret", "js_code", "grant_type"} {
+			if r.FormValue(v) == "" {
+				ret = `{"errcode":40029,"errmsg":"invalid code"}`
+				break
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := wxMiniObj.doCode2Session(ts.URL, "code")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(0, ret.ErrCode)
+	ast.Equal("OPENID", ret.OpenID)
+	ast.Equal("SESSIONKEY", ret.SessionKey)
+	ast.Equal("UNIONID", ret.UnionID)
+
+	// fail
+	ret, err = wxMiniObj.doCode2Session(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(40029, ret.ErrCode)
+}
+
+// TestWxMiniVerifyRawData
+func TestWxMiniVerifyRawData(t *testing.T) {
+
+	ast := assert.New(t)
+
+	rawData := `{"nickName":"Band","gender":1,"language":"zh_CN","city":"Guangzhou","province":"Guangdong","country":"CN","avatarUrl":"http://wx.qlogo.cn/mmopen/vi_32/1vZvI39NWFQ9XM4LtQpFrQJ1xlgZxx3w7bQxKARol6503Iuswjjn6nIGBiaycAjAtpujxyzYsrztuuICqIM5ibXQ/0"}`
+	sum := sha1.Sum([]byte(rawData + miniSessionKey))
+	signature := hex.EncodeToString(sum[:])
+
+	ast.True(wxMiniObj.VerifyRawData(rawData, signature, miniSessionKey))
+	ast.False(wxMiniObj.VerifyRawData(rawData+" ", signature, miniSessionKey))
+	ast.False(wxMiniObj.VerifyRawData(rawData, signature, "OTHER"))
+}
+
+// TestWxMiniDecrypt
+func TestWxMiniDecrypt(t *testing.T) {
+
+	ast := assert.New(t)
+
+	now := time.Now().Unix()
+
+	// phone number
+	phone := miniEncrypt(fmt.Sprintf(`{"phoneNumber":"+8613580006666","purePhoneNumber":"13580006666","countryCode":"86","watermark":{"appid":"wx4f4bc4dec97d474b","timestamp":%d}}`, now))
+	ret, err := wxMiniObj.DecryptPhoneNumber(miniSessionKey, phone, miniIV)
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("13580006666", ret.PurePhoneNumber)
+	ast.Equal("86", ret.CountryCode)
+	ast.Equal(now, ret.Watermark.Timestamp)
+
+	// user info
+	user := miniEncrypt(fmt.Sprintf(`{"openId":"OPENID","nickName":"Band","gender":1,"unionId":"UNIONID","watermark":{"appid":"wx4f4bc4dec97d474b","timestamp":%d}}`, now))
+	info, err := wxMiniObj.DecryptUserInfo(miniSessionKey, user, miniIV)
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("OPENID", info.OpenID)
+	ast.Equal("UNIONID", info.UnionID)
+
+	// watermark of another app
+	other := miniEncrypt(fmt.Sprintf(`{"phoneNumber":"+8613580006666","watermark":{"appid":"OTHER","timestamp":%d}}`, now))
+	_, err = wxMiniObj.DecryptPhoneNumber(miniSessionKey, other, miniIV)
+	ast.EqualError(err, "watermark appid is invalid")
+
+	// expired watermark
+	obj := *wxMiniObj
+	obj.WatermarkMaxAge = time.Minute
+	expired := miniEncrypt(fmt.Sprintf(`{"phoneNumber":"+8613580006666","watermark":{"appid":"wx4f4bc4dec97d474b","timestamp":%d}}`, now-3600))
+	_, err = obj.DecryptPhoneNumber(miniSessionKey, expired, miniIV)
+	ast.EqualError(err, "watermark is expired")
+
+	// invalid params
+	_, err = wxMiniObj.DecryptPhoneNumber("invalid", phone, miniIV)
+	ast.EqualError(err, "session_key is invalid")
+	_, err = wxMiniObj.DecryptPhoneNumber(miniSessionKey, "!!!", miniIV)
+	ast.EqualError(err, "encryptedData is invalid")
+}
@@ -0,0 +1,142 @@
+package socialite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	"strings"
+)
+
+const (
+	wxOAuthAuthorizeURL = "https://open.weixin.qq.com/connect/oauth2/authorize"
+	wxRedirectFragment  = "#wechat_redirect"
+
+	// WxScopeBase silent authorization, only openid is returned
+	WxScopeBase = "snsapi_base"
+	// WxScopeUserInfo authorization with user consent, user info is accessible
+	WxScopeUserInfo = "snsapi_userinfo"
+)
+
+// WechatOfficialAccount web authorization of official account, for h5 pages opened inside wechat
+// token, refresh token and user info share the endpoints of Wechat
+// @doc: https://developers.weixin.qq.com/doc/offiaccount/OA_Web_Apps/Wechat_webpage_authorization.html
+type WechatOfficialAccount struct {
+	Wechat
+	// Scope snsapi_base or snsapi_userinfo (default: snsapi_userinfo)
+	Scope string
+	// ForcePopup show the consent page even if the user has authorized
+	ForcePopup bool
+}
+
+// GetAuthorizeURL get authorize url, args: state, scope
+func (w *WechatOfficialAccount) GetAuthorizeURL(args ...string) string {
+
+	var state string
+	scope := w.scope()
+
+	length := len(args)
+	if length >= 1 {
+		state = args[0]
+		if length >= 2 && args[1] != "" {
+			scope = args[1]
+		}
+	}
+
+	return w.authorizeURL(w.RedirectURL, scope, state, w.ForcePopup)
+}
+
+// scope the configured scope, snsapi_userinfo by default
+func (w *WechatOfficialAccount) scope() string {
+	if w.Scope != "" {
+		return w.Scope
+	}
+	return WxScopeUserInfo
+}
+
+// AuthorizeURLWithOptions get authorize url, Scope and ForcePopup of the options take precedence
+func (w *WechatOfficialAccount) AuthorizeURLWithOptions(opts WechatAuthorizeOptions) (string, error) {
+
+	if err := checkRedirectURL(opts.RedirectURL, w.RedirectURL, w.RedirectAllowlist); err != nil {
+		return "", err
+	}
+
+	scope := w.scope()
+	if opts.Scope != "" {
+		scope = opts.Scope
+	}
+	if scope != WxScopeBase && scope != WxScopeUserInfo {
+		return "", fmt.Errorf("scope %q is invalid", scope)
+	}
+	if opts.Lang != "" {
+		return "", errors.New("lang is not supported by the official account")
+	}
+
+	redirectURL := w.RedirectURL
+	if opts.RedirectURL != "" {
+		redirectURL = opts.RedirectURL
+	}
+
+	return w.authorizeURL(redirectURL, scope, opts.State, w.ForcePopup || opts.ForcePopup), nil
+}
+
+// authorizeURL build the authorize url without validation
+func (w *WechatOfficialAccount) authorizeURL(redirectURL, scope, state string, forcePopup bool) string {
+
+	params := map[string]string{
+		"appid":         w.AppID,
+		"redirect_uri":  redirectURL,
+		"response_type": wxResponseType,
+		"scope":         scope,
+	}
+	if state != "" {
+		params["state"] = state
+	}
+
+	// wechat requires the order: appid, redirect_uri, response_type, scope, state, which is the sorted one
+	query := utils.QuerySortByKeyStr2(params)
+	if forcePopup {
+		query += "&forcePopup=true"
+	}
+
+	return fmt.Sprintf("%s?%s%s", wxOAuthAuthorizeURL, query, wxRedirectFragment)
+}
+
+// AuthorizeURL get authorize url, prompt consent is mapped to forcePopup
+func (w *WechatOfficialAccount) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authState, authScopes, authRedirectURL, authPrompt, authGranted); err != nil {
+		return "", err
+	}
+	scope, err := o.joinScopes(WechatOfficialAccountScopeCatalog)
+	if err != nil {
+		return "", err
+	}
+	if o.Prompt != "" && o.Prompt != "consent" {
+		return "", fmt.Errorf("prompt %q is invalid", o.Prompt)
+	}
+
+	ret, err := w.AuthorizeURLWithOptions(WechatAuthorizeOptions{
+		State:       o.State,
+		Scope:       scope,
+		ForcePopup:  o.Prompt == "consent",
+		RedirectURL: o.RedirectURL,
+	})
+	if err != nil {
+		return "", err
+	}
+	return o.withExtra(ret)
+}
+
+// IsWechatBrowser whether the user agent is the built-in browser of wechat
+func IsWechatBrowser(userAgent string) bool {
+	return strings.Contains(userAgent, "MicroMessenger")
+}
+
+// PickWechat pick the official account flow inside wechat and the qr connect flow elsewhere
+func PickWechat(userAgent string, website *Wechat, officialAccount *WechatOfficialAccount) ISocialite {
+	if IsWechatBrowser(userAgent) {
+		return officialAccount
+	}
+	return website
+}
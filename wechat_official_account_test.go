@@ -0,0 +1,75 @@
+package socialite
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var (
+	wxOaObj = &WechatOfficialAccount{
+		Wechat: Wechat{
+			AppID:       "APPID",
+			AppSecret:   "SECRET",
+			RedirectURL: "https://domain.com/wx/callback",
+			HTTPRequest: wxHTTPClient,
+		},
+	}
+)
+
+// TestWxOaGetAuthorizeURL test GetAuthorizeURL
+func TestWxOaGetAuthorizeURL(t *testing.T) {
+
+	url1 := "https://open.weixin.qq.com/connect/oauth2/authorize?appid=APPID&redirect_uri=https%3A%2F%2Fdomain.com%2Fwx%2Fcallback&response_type=code&scope=snsapi_userinfo&state=STATE#wechat_redirect"
+	url2 := "https://open.weixin.qq.com/connect/oauth2/authorize?appid=APPID&redirect_uri=https%3A%2F%2Fdomain.com%2Fwx%2Fcallback&response_type=code&scope=snsapi_base&state=STATE#wechat_redirect"
+	url3 := "https://open.weixin.qq.com/connect/oauth2/authorize?appid=APPID&redirect_uri=https%3A%2F%2Fdomain.com%2Fwx%2Fcallback&response_type=code&scope=snsapi_userinfo&state=STATE&forcePopup=true#wechat_redirect"
+
+	ast := assert.New(t)
+
+	ast.Equal(url1, wxOaObj.GetAuthorizeURL("STATE"))
+	ast.Equal(url2, wxOaObj.GetAuthorizeURL("STATE", WxScopeBase))
+
+	obj := *wxOaObj
+	obj.Scope = WxScopeBase
+	ast.Equal(url2, obj.GetAuthorizeURL("STATE"))
+
+	obj.Scope = ""
+	obj.ForcePopup = true
+	ast.Equal(url3, obj.GetAuthorizeURL("STATE"))
+}
+
+// TestWxOaAuthorizeURLWithOptions
+func TestWxOaAuthorizeURLWithOptions(t *testing.T) {
+
+	url1 := "https://open.weixin.qq.com/connect/oauth2/authorize?appid=APPID&redirect_uri=https%3A%2F%2Fdomain.com%2Fwx%2Fcallback&response_type=code&scope=snsapi_base&state=STATE&forcePopup=true#wechat_redirect"
+
+	ast := assert.New(t)
+
+	// success
+	ret, err := wxOaObj.AuthorizeURLWithOptions(WechatAuthorizeOptions{State: "STATE", Scope: WxScopeBase, ForcePopup: true})
+	ast.Nil(err)
+	ast.Equal(url1, ret)
+
+	// fail
+	_, err = wxOaObj.AuthorizeURLWithOptions(WechatAuthorizeOptions{State: "STATE", Scope: "snsapi_login"})
+	ast.Error(err)
+	_, err = wxOaObj.AuthorizeURLWithOptions(WechatAuthorizeOptions{State: "STATE", Lang: "en"})
+	ast.Error(err)
+
+	// the positional args are passed through without validation
+	ast.Equal("https://open.weixin.qq.com/connect/oauth2/authorize?appid=APPID&redirect_uri=https%3A%2F%2Fdomain.com%2Fwx%2Fcallback&response_type=code&scope=snsapi_login&state=STATE#wechat_redirect", wxOaObj.GetAuthorizeURL("STATE", "snsapi_login"))
+}
+
+// TestPickWechat
+func TestPickWechat(t *testing.T) {
+
+	ast := assert.New(t)
+
+	inApp := "Mozilla/5.0 (iPhone; CPU iPhone OS 13_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/7.0.15(0x17000f27) NetType/WIFI Language/zh_CN"
+	desktop := "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/85.0.4183.102 Safari/537.36"
+
+	ast.True(IsWechatBrowser(inApp))
+	ast.False(IsWechatBrowser(desktop))
+
+	ast.Equal(wxOaObj, PickWechat(inApp, wxObj, wxOaObj))
+	ast.Equal(wxObj, PickWechat(desktop, wxObj, wxOaObj))
+}
@@ -1,6 +1,7 @@
 package socialite
 
 import (
+	"errors"
 	"github.com/birjemin/socialite/utils"
 	"github.com/stretchr/testify/assert"
 	"net/http"
@@ -36,6 +37,27 @@ func TestWxGetAuthorizeURL(t *testing.T) {
 	ast.Equal(url2, wxObj.GetAuthorizeURL("SCOPE", "STATE"))
 }
 
+// TestWxAuthorizeURLWithOptions
+func TestWxAuthorizeURLWithOptions(t *testing.T) {
+
+	url1 := "https://open.weixin.qq.com/connect/qrconnect?appid=APPID&lang=en&redirect_uri=REDIRECT_URI&response_type=code&scope=snsapi_login&state=STATE"
+
+	ast := assert.New(t)
+
+	// success
+	ret, err := wxObj.AuthorizeURLWithOptions(WechatAuthorizeOptions{State: "STATE", Lang: "en"})
+	ast.Nil(err)
+	ast.Equal(url1, ret)
+
+	// fail
+	_, err = wxObj.AuthorizeURLWithOptions(WechatAuthorizeOptions{Scope: WxScopeBase})
+	ast.Error(err)
+	_, err = wxObj.AuthorizeURLWithOptions(WechatAuthorizeOptions{Lang: "jp"})
+	ast.Error(err)
+	_, err = wxObj.AuthorizeURLWithOptions(WechatAuthorizeOptions{ForcePopup: true})
+	ast.Error(err)
+}
+
 // TestWxToken
 func TestWxToken(t *testing.T) {
 
@@ -80,6 +102,29 @@ func TestWxToken(t *testing.T) {
 	ast.Equal(40029, ret.ErrCode)
 }
 
+// TestWxTokenErrorPage
+func TestWxTokenErrorPage(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		if _, err := w.Write([]byte("<html>502 Bad Gateway</html>")); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	ret, err := wxObj.doToken(ts.URL, "code")
+	ast.Nil(ret)
+
+	var statusErr *utils.HTTPStatusError
+	ast.True(errors.As(err, &statusErr))
+	ast.Equal(http.StatusBadGateway, statusErr.StatusCode)
+	ast.NotContains(statusErr.URL, "SECRET")
+}
+
 // TestWxRefreshToken
 func TestWxRefreshToken(t *testing.T) {
 
@@ -164,3 +209,167 @@ func TestWxUserInfo(t *testing.T) {
 
 	ast.Equal(40003, ret.ErrCode)
 }
+
+// TestWxValidateToken
+func TestWxValidateToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var ret string
+		switch r.FormValue("access_token") {
+		case "VALID":
+			ret = `{"errcode":0,"errmsg":"ok"}`
+		case "BUSY":
+			ret = `{"errcode":-1,"errmsg":"system error"}`
+		default:
+			ret = `{"errcode":42001,"errmsg":"access_token expired"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	valid, err := wxObj.doValidateToken(ts.URL, "VALID", "OPENID")
+	ast.Nil(err)
+	ast.True(valid)
+
+	valid, err = wxObj.doValidateToken(ts.URL, "EXPIRED", "OPENID")
+	ast.Nil(err)
+	ast.False(valid)
+
+	_, err = wxObj.doValidateToken(ts.URL, "BUSY", "OPENID")
+	ast.EqualError(err, "validate token error: -1 system error")
+}
+
+// TestWxGetUserInfoByToken
+func TestWxGetUserInfoByToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sns/auth", func(w http.ResponseWriter, r *http.Request) {
+		ret := `{"errcode":42001,"errmsg":"access_token expired"}`
+		if r.FormValue("access_token") == "NEW_ACCESS_TOKEN" {
+			ret = `{"errcode":0,"errmsg":"ok"}`
+		}
+		_, _ = w.Write([]byte(ret))
+	})
+	mux.HandleFunc("/sns/oauth2/refresh_token", func(w http.ResponseWriter, r *http.Request) {
+		ret := `{"access_token":"NEW_ACCESS_TOKEN","expires_in":7200,"refresh_token":"NEW_REFRESH_TOKEN","openid":"OPENID","scope":"snsapi_userinfo"}`
+		if r.FormValue("refresh_token") != "REFRESH_TOKEN" {
+			ret = `{"errcode":42002,"errmsg":"refresh_token expired"}`
+		}
+		_, _ = w.Write([]byte(ret))
+	})
+	mux.HandleFunc("/sns/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		ret := `{"openid":"OPENID","nickname":"NICKNAME"}`
+		if r.FormValue("access_token") != "NEW_ACCESS_TOKEN" {
+			ret = `{"errcode":42001,"errmsg":"access_token expired"}`
+		}
+		_, _ = w.Write([]byte(ret))
+	})
+
+	var ts = httptest.NewServer(mux)
+	defer ts.Close()
+
+	authURL, refreshURL, userInfoURL := ts.URL+"/sns/auth", ts.URL+"/sns/oauth2/refresh_token", ts.URL+"/sns/userinfo"
+
+	// expired token is refreshed
+	token := &WxRespToken{AccessToken: "OLD_ACCESS_TOKEN", RefreshToken: "REFRESH_TOKEN", OpenID: "OPENID", UnionID: "UNIONID"}
+	ret, err := wxObj.doGetUserInfoByToken(authURL, refreshURL, userInfoURL, token)
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("NICKNAME", ret.Nickname)
+	ast.Equal("NEW_ACCESS_TOKEN", token.AccessToken)
+	ast.Equal("NEW_REFRESH_TOKEN", token.RefreshToken)
+	ast.Equal("UNIONID", token.UnionID)
+
+	// valid token is not refreshed
+	refreshed, err := wxObj.doEnsureToken(authURL, refreshURL, token)
+	ast.Nil(err)
+	ast.False(refreshed)
+
+	// expired refresh token
+	token = &WxRespToken{AccessToken: "OLD_ACCESS_TOKEN", RefreshToken: "EXPIRED", OpenID: "OPENID"}
+	_, err = wxObj.doGetUserInfoByToken(authURL, refreshURL, userInfoURL, token)
+	ast.EqualError(err, "refresh token error, re-authorize: 42002 refresh_token expired")
+}
+
+// TestWxScopes
+func TestWxScopes(t *testing.T) {
+
+	ast := assert.New(t)
+
+	ast.Equal([]Scope{"snsapi_base", "snsapi_userinfo"}, (&WxRespToken{Scope: "snsapi_base, snsapi_userinfo"}).Scopes())
+	ast.Equal([]Scope{WxScopeLogin}, (&WxRespToken{Scope: "snsapi_login"}).Scopes())
+	ast.Nil((&WxRespToken{}).Scopes())
+}
+
+// TestWxUserInfoWithLang
+func TestWxUserInfoWithLang(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"openid":"YOUR_OPENID","nickname":"NICKNAME","sex":2,"province":"广东","city":"广州","country":"中国","headimgurl":"https://thirdwx.qlogo.cn/mmopen/g3MonUZtNHkdmzicIlibx6iaFqAc56vxLSUfpb6n5WKSYVY0ChQKkiaJSgQ1dZuTOgvLLrhJbERQQ4eMsv84eavHiaiceqxibJxCfHe/132","privilege":["PRIVILEGE1","PRIVILEGE2"],"unionid":"UNIONID"}`
+		if r.FormValue("lang") == "en" {
+			ret = `{"openid":"YOUR_OPENID","nickname":"NICKNAME","sex":1,"province":"Guangdong","city":"Guangzhou","country":"CN","headimgurl":"","privilege":[]}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// default
+	ret, err := wxObj.doGetUserInfo(ts.URL, "YOUR_ACCESS_TOKEN", "YOUR_OPENID")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("广东", ret.Province)
+	ast.Equal([]string{"PRIVILEGE1", "PRIVILEGE2"}, ret.Privilege)
+	ast.Equal(WxGenderFemale, ret.Gender())
+	ast.Equal("female", ret.Gender().String())
+
+	url, err := ret.HeadImgURLWithSize(46)
+	ast.Nil(err)
+	ast.Equal("https://thirdwx.qlogo.cn/mmopen/g3MonUZtNHkdmzicIlibx6iaFqAc56vxLSUfpb6n5WKSYVY0ChQKkiaJSgQ1dZuTOgvLLrhJbERQQ4eMsv84eavHiaiceqxibJxCfHe/46", url)
+	url, err = ret.HeadImgURLWithSize(0)
+	ast.Nil(err)
+	ast.Equal("https://thirdwx.qlogo.cn/mmopen/g3MonUZtNHkdmzicIlibx6iaFqAc56vxLSUfpb6n5WKSYVY0ChQKkiaJSgQ1dZuTOgvLLrhJbERQQ4eMsv84eavHiaiceqxibJxCfHe/0", url)
+	_, err = ret.HeadImgURLWithSize(100)
+	ast.Error(err)
+
+	// english
+	ret, err = wxObj.doGetUserInfoWithLang(ts.URL, "YOUR_ACCESS_TOKEN", "YOUR_OPENID", WxLangEn)
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("Guangdong", ret.Province)
+	ast.Equal(WxGenderMale, ret.Gender())
+	url, _ = ret.HeadImgURLWithSize(64)
+	ast.Equal("", url)
+
+	obj := *wxObj
+	obj.Lang = WxLangEn
+	ret, _ = obj.doGetUserInfo(ts.URL, "YOUR_ACCESS_TOKEN", "YOUR_OPENID")
+	ast.Equal("Guangdong", ret.Province)
+
+	// invalid lang
+	_, err = wxObj.doGetUserInfoWithLang(ts.URL, "YOUR_ACCESS_TOKEN", "YOUR_OPENID", "fr")
+	ast.Error(err)
+}
@@ -1,9 +1,14 @@
 package socialite
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/birjemin/socialite/utils"
+	jsoniter "github.com/json-iterator/go"
+	"strconv"
+	"time"
 )
 
 const (
@@ -15,14 +20,40 @@ const (
 	wbGrantTypeAuth = "authorization_code"
 
 	wbUserInfoURL = "https://api.weibo.com/2/users/show.json"
+
+	wbEmailURL      = "https://api.weibo.com/2/account/profile/email.json"
+	wbDomainShowURL = "https://api.weibo.com/2/users/domain_show.json"
+	wbRateLimitURL  = "https://api.weibo.com/2/account/rate_limit_status.json"
+
+	wbTokenInfoURL = "https://api.weibo.com/oauth2/get_token_info"
+	wbRevokeURL    = "https://api.weibo.com/oauth2/revokeoauth2"
+
+	// default duration before the expiry to ask the user to authorize again
+	wbReauthorizeAhead = 24 * time.Hour
 )
 
+// display of the authorize page
+const (
+	WbDisplayDefault    = "default"
+	WbDisplayMobile     = "mobile"
+	WbDisplayWap        = "wap"
+	WbDisplayClient     = "client"
+	WbDisplayAppOnWeibo = "apponweibo"
+)
+
+// ErrWbReauthorize weibo does not issue refresh tokens to website apps, the user has to authorize again
+var ErrWbReauthorize = errors.New("weibo does not support refresh token, please authorize again")
+
 // Weibo struct
 type Weibo struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
-	HTTPRequest  *utils.HTTPClient
+	// RedirectAllowlist allowed per-request redirect urls besides RedirectURL
+	RedirectAllowlist RedirectAllowlist
+	// RedirectStore keeps the per-request redirect url by state for TokenWithState
+	RedirectStore RedirectStore
+	HTTPRequest   *utils.HTTPClient
 }
 
 // wbRespErrorToken response of err
@@ -43,10 +74,48 @@ type WbRespToken struct {
 	IsRealName  string `json:"isRealName"`
 }
 
+// WbTokenInfo response of get_token_info
+type WbTokenInfo struct {
+	wbRespErrorToken
+	UID      int64       `json:"uid"`
+	AppKey   json.Number `json:"appkey"`
+	Scope    string      `json:"scope"`
+	CreateAt int64       `json:"create_at"`
+	// ExpireIn seconds left
+	ExpireIn int64 `json:"expire_in"`
+}
+
+// Scopes granted scopes
+func (t *WbTokenInfo) Scopes() []Scope {
+	return ParseScopes(t.Scope)
+}
+
+// ExpiresAt expiry of the token, relative to now
+func (t *WbTokenInfo) ExpiresAt() time.Time {
+	return time.Now().Add(time.Duration(t.ExpireIn) * time.Second)
+}
+
+// NeedsReauthorize the token expires within the threshold (default: 24h), since it can not be refreshed
+// the user should be sent to the authorize url again
+func (t *WbTokenInfo) NeedsReauthorize(threshold time.Duration) bool {
+	if threshold <= 0 {
+		threshold = wbReauthorizeAhead
+	}
+	return time.Duration(t.ExpireIn)*time.Second < threshold
+}
+
+// WbRespRevoke response of revokeoauth2
+type WbRespRevoke struct {
+	wbRespErrorToken
+	Result string `json:"result"`
+}
+
 // WbUserInfo user info
 type WbUserInfo struct {
 	wbRespErrorToken
-	ID              int    `json:"id"`
+	// ID exceeds 32-bit, use IDStr or UID() as the identifier
+	ID              int64  `json:"id"`
+	IDStr           string `json:"idstr"`
 	ScreenName      string `json:"screen_name"`
 	Name            string `json:"name"`
 	Province        string `json:"province"`
@@ -72,7 +141,8 @@ type WbUserInfo struct {
 		CreatedAt           string        `json:"created_at"`
 		Favorited           bool          `json:"favorited"`
 		Geo                 string        `json:"geo"`
-		ID                  int           `json:"id"`
+		ID                  int64         `json:"id"`
+		IDStr               string        `json:"idstr"`
 		InReplyToScreenName string        `json:"in_reply_to_screen_name"`
 		InReplyToStatusID   string        `json:"in_reply_to_status_id"`
 		InReplyToUserID     string        `json:"in_reply_to_user_id"`
@@ -90,37 +160,193 @@ type WbUserInfo struct {
 	BiFollowersCount int    `json:"bi_followers_count"`
 }
 
-// GetAuthorizeURL get authorize url
+// WeiboAuthorizeOptions options of the authorize url, empty ones are omitted
+type WeiboAuthorizeOptions struct {
+	State string
+	// Display default, mobile, wap, client or apponweibo
+	Display string
+	// ForceLogin ask the user to login again even if logged in
+	ForceLogin bool
+	// Scope comma separated, e.g. email,direct_messages_read
+	Scope string
+	// Language en for english (default: simplified chinese)
+	Language string
+	// RedirectURL overrides Weibo.RedirectURL
+	RedirectURL string
+}
+
+// validate validate the options
+func (o *WeiboAuthorizeOptions) validate() error {
+	switch o.Display {
+	case "", WbDisplayDefault, WbDisplayMobile, WbDisplayWap, WbDisplayClient, WbDisplayAppOnWeibo:
+	default:
+		return fmt.Errorf("display %q is invalid", o.Display)
+	}
+	if o.Language != "" && o.Language != "en" {
+		return fmt.Errorf("language %q is invalid", o.Language)
+	}
+	return WeiboScopeCatalog.Validate(ParseScopes(o.Scope)...)
+}
+
+// UID the user id as a string, idstr is preferred
+func (u *WbUserInfo) UID() string {
+	if u.IDStr != "" {
+		return u.IDStr
+	}
+	if u.ID == 0 {
+		return ""
+	}
+	return strconv.FormatInt(u.ID, 10)
+}
+
+// WbRespEmail response of account/profile/email
+type WbRespEmail struct {
+	wbRespErrorToken
+	Email string `json:"email"`
+}
+
+// WbAPIRateLimit rate limit of an api
+type WbAPIRateLimit struct {
+	API           string `json:"api"`
+	Limit         int    `json:"limit"`
+	LimitTimeUnit string `json:"limit_time_unit"`
+	RemainingHits int    `json:"remaining_hits"`
+}
+
+// WbRateLimitStatus response of account/rate_limit_status
+type WbRateLimitStatus struct {
+	wbRespErrorToken
+	APIRateLimits      []WbAPIRateLimit `json:"api_rate_limits"`
+	IPLimit            int              `json:"ip_limit"`
+	LimitTimeUnit      string           `json:"limit_time_unit"`
+	RemainingIPHits    int              `json:"remaining_ip_hits"`
+	RemainingUserHits  int              `json:"remaining_user_hits"`
+	ResetTime          string           `json:"reset_time"`
+	ResetTimeInSeconds int              `json:"reset_time_in_seconds"`
+	UserLimit          int              `json:"user_limit"`
+}
+
+// Remaining remaining hits of the api, the user limit is used when the api is not listed
+func (r *WbRateLimitStatus) Remaining(api string) int {
+	for _, v := range r.APIRateLimits {
+		if v.API == api {
+			return v.RemainingHits
+		}
+	}
+	return r.RemainingUserHits
+}
+
+// GetAuthorizeURL get authorize url, args: state, display, forcelogin, scope, language
+// the args are passed through as is and the empty ones are omitted, use AuthorizeURLWithOptions for validation
 // @doc: https://open.weibo.com/wiki/%E6%8E%88%E6%9D%83%E6%9C%BA%E5%88%B6%E8%AF%B4%E6%98%8E
 // @doc: https://open.weibo.com/wiki/Oauth2/authorize
 // @explain: two document, ridiculous~
 func (w *Weibo) GetAuthorizeURL(args ...string) string {
 
+	params := make(map[string]string, 5)
+	for k, name := range []string{"state", "display", "forcelogin", "scope", "language"} {
+		if k < len(args) {
+			params[name] = args[k]
+		}
+	}
+
+	return w.authorizeURL(w.RedirectURL, params)
+}
+
+// AuthorizeURLWithOptions get authorize url
+func (w *Weibo) AuthorizeURLWithOptions(opts WeiboAuthorizeOptions) (string, error) {
+
+	ret, err := w.checkedAuthorizeURL(opts)
+	if err != nil {
+		return "", err
+	}
+	if err := bindRedirectURL(w.RedirectStore, opts.State, opts.RedirectURL, w.RedirectURL); err != nil {
+		return "", err
+	}
+	return ret, nil
+}
+
+// checkedAuthorizeURL validate the options and build the authorize url, the redirect url is not bound
+func (w *Weibo) checkedAuthorizeURL(opts WeiboAuthorizeOptions) (string, error) {
+
+	if err := checkRedirectURL(opts.RedirectURL, w.RedirectURL, w.RedirectAllowlist); err != nil {
+		return "", err
+	}
+
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+
+	redirectURL := w.RedirectURL
+	if opts.RedirectURL != "" {
+		redirectURL = opts.RedirectURL
+	}
+
+	optional := map[string]string{
+		"state":    opts.State,
+		"display":  opts.Display,
+		"scope":    opts.Scope,
+		"language": opts.Language,
+	}
+	if opts.ForceLogin {
+		optional["forcelogin"] = "true"
+	}
+
+	return w.authorizeURL(redirectURL, optional), nil
+}
+
+// authorizeURL build the authorize url without validation, empty params are omitted
+func (w *Weibo) authorizeURL(redirectURL string, optional map[string]string) string {
+
 	params := map[string]string{
 		"client_id":    w.ClientID,
-		"redirect_uri": w.RedirectURL,
-	}
-
-	length := len(args)
-	if length >= 1 {
-		params["state"] = args[0]
-		if length >= 2 {
-			params["display"] = args[1]
-			if length >= 3 {
-				params["forcelogin"] = args[2]
-				if length >= 4 {
-					params["scope"] = args[3]
-					if length >= 5 {
-						params["language"] = args[4]
-					}
-				}
-			}
+		"redirect_uri": redirectURL,
+	}
+	for k, v := range optional {
+		if v != "" {
+			params[k] = v
 		}
 	}
 
 	return fmt.Sprintf("%s?%s", wbAuthorizeURL, utils.QuerySortByKeyStr2(params))
 }
 
+// AuthorizeURL get authorize url, prompt login is mapped to forcelogin, extra e.g. language
+func (w *Weibo) AuthorizeURL(opts ...AuthOption) (string, error) {
+
+	o := newAuthOptions(opts)
+	if err := o.supports(authState, authScopes, authDisplay, authRedirectURL, authPrompt, authGranted); err != nil {
+		return "", err
+	}
+	scope, err := o.joinScopes(WeiboScopeCatalog)
+	if err != nil {
+		return "", err
+	}
+	if o.Prompt != "" && o.Prompt != "login" {
+		return "", fmt.Errorf("prompt %q is invalid", o.Prompt)
+	}
+
+	// weibo does not support incremental consent, the user has to authorize all the scopes again
+	opt := WeiboAuthorizeOptions{
+		State:       o.State,
+		Display:     o.Display,
+		ForceLogin:  o.Prompt == "login" || o.incremental(),
+		Scope:       scope,
+		RedirectURL: o.RedirectURL,
+	}
+	ret, err := w.checkedAuthorizeURL(opt)
+	if err != nil {
+		return "", err
+	}
+	if ret, err = o.withExtra(ret); err != nil {
+		return "", err
+	}
+	if err := bindRedirectURL(w.RedirectStore, opt.State, opt.RedirectURL, w.RedirectURL); err != nil {
+		return "", err
+	}
+	return ret, nil
+}
+
 // Token token
 func (w *Weibo) Token(code string) (interface{}, error) {
 	return w.doToken(wbTokenURL, code)
@@ -128,28 +354,124 @@ func (w *Weibo) Token(code string) (interface{}, error) {
 
 // doToken handle
 func (w *Weibo) doToken(url, code string) (ret *WbRespToken, err error) {
+	return w.doTokenWithRedirectURL(url, code, w.RedirectURL)
+}
+
+// TokenWithRedirectURL get token with the redirect url of the authorize url,
+// it must be RedirectURL or allowed by RedirectAllowlist
+func (w *Weibo) TokenWithRedirectURL(code, redirectURL string) (interface{}, error) {
+
+	if redirectURL == "" {
+		redirectURL = w.RedirectURL
+	}
+	if err := checkRedirectURL(redirectURL, w.RedirectURL, w.RedirectAllowlist); err != nil {
+		return nil, err
+	}
+	return w.doTokenWithRedirectURL(wbTokenURL, code, redirectURL)
+}
+
+// TokenWithState get token with the redirect url bound to the state by the authorize url, see RedirectStore
+func (w *Weibo) TokenWithState(code, state string) (interface{}, error) {
+	redirectURL, err := boundRedirectURL(w.RedirectStore, state, w.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return w.TokenWithRedirectURL(code, redirectURL)
+}
+
+// doTokenWithRedirectURL handle
+func (w *Weibo) doTokenWithRedirectURL(url, code, redirectURL string) (ret *WbRespToken, err error) {
 	params := map[string]string{
 		"grant_type":    wbGrantTypeAuth,
 		"client_id":     w.ClientID,
 		"client_secret": w.ClientSecret,
-		"redirect_uri":  w.RedirectURL,
+		"redirect_uri":  redirectURL,
 		"code":          code,
 	}
 
-	if err := w.HTTPRequest.HTTPPost(url, params); err != nil {
+	if err := w.HTTPRequest.HTTPPost(url, params, utils.NonIdempotent()); err != nil {
 		return nil, err
 	}
 
 	ret = new(WbRespToken)
-	if w.HTTPRequest.GetResponseJSON(ret) != nil {
+	if err = w.HTTPRequest.GetResponseJSON(ret); err != nil {
 		return nil, err
 	}
 	return ret, nil
 }
 
-// RefreshToken refresh token
+// RefreshToken weibo website apps can not refresh tokens, ErrWbReauthorize is returned
 func (w *Weibo) RefreshToken(refreshToken string) (interface{}, error) {
-	return nil, errors.New("invalid")
+	return nil, ErrWbReauthorize
+}
+
+// TokenInfo get token info, e.g. check the token sent by the mobile app
+// @doc: https://open.weibo.com/wiki/Oauth2/get_token_info
+func (w *Weibo) TokenInfo(accessToken string) (*WbTokenInfo, error) {
+	return w.doTokenInfo(wbTokenInfoURL, accessToken)
+}
+
+// doTokenInfo handle
+func (w *Weibo) doTokenInfo(url, accessToken string) (*WbTokenInfo, error) {
+
+	params := map[string]string{
+		"access_token": accessToken,
+	}
+
+	if err := w.HTTPRequest.HTTPPost(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(WbTokenInfo)
+	if err := w.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// VerifyToken check the token is valid and issued for this app
+func (w *Weibo) VerifyToken(accessToken string) (*WbTokenInfo, error) {
+	return w.doVerifyToken(wbTokenInfoURL, accessToken)
+}
+
+// doVerifyToken handle
+func (w *Weibo) doVerifyToken(url, accessToken string) (*WbTokenInfo, error) {
+
+	ret, err := w.doTokenInfo(url, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if ret.ErrorCode != 0 || ret.ExpireIn <= 0 {
+		return ret, errors.New("token is invalid")
+	}
+	if ret.AppKey.String() != w.ClientID {
+		return ret, errors.New("token is not issued for this app")
+	}
+	return ret, nil
+}
+
+// RevokeToken revoke the authorization of the user
+// @doc: https://open.weibo.com/wiki/Oauth2/revokeoauth2
+func (w *Weibo) RevokeToken(accessToken string) (*WbRespRevoke, error) {
+	return w.doRevokeToken(wbRevokeURL, accessToken)
+}
+
+// doRevokeToken handle
+func (w *Weibo) doRevokeToken(url, accessToken string) (*WbRespRevoke, error) {
+
+	params := map[string]string{
+		"access_token": accessToken,
+	}
+
+	if err := w.HTTPRequest.HTTPPost(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(WbRespRevoke)
+	if err := w.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
 // GetMe get me
@@ -180,3 +502,117 @@ func (w *Weibo) doGetUserInfo(url, accessToken, UID string) (*WbUserInfo, error)
 	}
 	return ret, nil
 }
+
+// GetEmail get email of the user, the email scope is required
+// @doc: https://open.weibo.com/wiki/2/account/profile/email
+func (w *Weibo) GetEmail(accessToken string) (*WbRespEmail, error) {
+	return w.doGetEmail(wbEmailURL, accessToken)
+}
+
+// doGetEmail handle, the email is returned as an array
+func (w *Weibo) doGetEmail(url, accessToken string) (*WbRespEmail, error) {
+
+	params := map[string]string{
+		"access_token": accessToken,
+	}
+
+	if err := w.HTTPRequest.HTTPGet(url, params); err != nil {
+		return nil, err
+	}
+
+	b, err := w.HTTPRequest.GetResponseByte()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := new(WbRespEmail)
+	if body := bytes.TrimSpace(b); len(body) > 0 && body[0] == '[' {
+		var emails []WbRespEmail
+		if err := jsoniter.Unmarshal(body, &emails); err != nil {
+			return nil, err
+		}
+		if len(emails) > 0 {
+			ret.Email = emails[0].Email
+		}
+		return ret, nil
+	}
+
+	if err := jsoniter.Unmarshal(b, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetUserInfoByScreenName get user info by the nickname
+// @doc: https://open.weibo.com/wiki/2/users/show
+func (w *Weibo) GetUserInfoByScreenName(accessToken, screenName string) (*WbUserInfo, error) {
+	return w.doGetUserInfoByScreenName(wbUserInfoURL, accessToken, screenName)
+}
+
+// doGetUserInfoByScreenName handle
+func (w *Weibo) doGetUserInfoByScreenName(url, accessToken, screenName string) (*WbUserInfo, error) {
+
+	params := map[string]string{
+		"access_token": accessToken,
+		"screen_name":  screenName,
+	}
+
+	if err := w.HTTPRequest.HTTPGet(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(WbUserInfo)
+	if err := w.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetUserInfoByDomain get user info by the personal domain
+// @doc: https://open.weibo.com/wiki/2/users/domain_show
+func (w *Weibo) GetUserInfoByDomain(accessToken, domain string) (*WbUserInfo, error) {
+	return w.doGetUserInfoByDomain(wbDomainShowURL, accessToken, domain)
+}
+
+// doGetUserInfoByDomain handle
+func (w *Weibo) doGetUserInfoByDomain(url, accessToken, domain string) (*WbUserInfo, error) {
+
+	params := map[string]string{
+		"access_token": accessToken,
+		"domain":       domain,
+	}
+
+	if err := w.HTTPRequest.HTTPGet(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(WbUserInfo)
+	if err := w.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetRateLimitStatus get the remaining quota of the token
+// @doc: https://open.weibo.com/wiki/2/account/rate_limit_status
+func (w *Weibo) GetRateLimitStatus(accessToken string) (*WbRateLimitStatus, error) {
+	return w.doGetRateLimitStatus(wbRateLimitURL, accessToken)
+}
+
+// doGetRateLimitStatus handle
+func (w *Weibo) doGetRateLimitStatus(url, accessToken string) (*WbRateLimitStatus, error) {
+
+	params := map[string]string{
+		"access_token": accessToken,
+	}
+
+	if err := w.HTTPRequest.HTTPGet(url, params); err != nil {
+		return nil, err
+	}
+
+	ret := new(WbRateLimitStatus)
+	if err := w.HTTPRequest.GetResponseJSON(ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
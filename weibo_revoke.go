@@ -0,0 +1,204 @@
+package socialite
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	jsoniter "github.com/json-iterator/go"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	wbSignedRequestAlgorithm = "HMAC-SHA256"
+
+	// signed_request older than it is rejected so that it can not be replayed
+	wbSignedRequestMaxAge = 10 * time.Minute
+	// allowed clock skew of issued_at
+	wbSignedRequestClockSkew = 5 * time.Minute
+)
+
+// wbTokenInvalidCodes error codes of get_token_info meaning the token is expired or revoked
+// @doc: https://open.weibo.com/wiki/Error_code
+var wbTokenInvalidCodes = []int{21315, 21316, 21317, 21327, 21332}
+
+// WbSignedRequest payload of signed_request, posted to the canvas page of in-site apps
+// @doc: https://open.weibo.com/wiki/%E7%AB%99%E5%86%85%E5%BA%94%E7%94%A8%E5%BC%80%E5%8F%91%E6%8C%87%E5%8D%97
+type WbSignedRequest struct {
+	Algorithm string `json:"algorithm"`
+	IssuedAt  int64  `json:"issued_at"`
+	Expires   int64  `json:"expires"`
+	// OAuthToken empty when the user has not authorized the app
+	OAuthToken string `json:"oauth_token"`
+	UserID     string `json:"user_id"`
+	Referer    string `json:"referer"`
+	User       struct {
+		Country string `json:"country"`
+		Locale  string `json:"locale"`
+		Version int    `json:"version"`
+	} `json:"user"`
+}
+
+// WbRevokeEvent the user revoked the authorization
+type WbRevokeEvent struct {
+	UID string
+	// AuthEnd time of the revocation
+	AuthEnd time.Time
+	// Verified weibo rejected the stored token of the user, only then it is safe to drop the stored
+	// tokens and profile, the callback itself is not signed and anyone knowing the appkey can send it
+	Verified bool
+}
+
+// WbRevokeHandler http.Handler of the cancel-authorization callback (取消授权回调页)
+// weibo calls it with source (appkey), uid and auth_end, which are not signed, set TokenOf so that
+// the revocation is confirmed with the stored token before OnRevoke is called
+type WbRevokeHandler struct {
+	Weibo *Weibo
+	// TokenOf stored access token of the uid, "" when there is none, the event is dropped when the token
+	// is still valid, without it the events are not verified
+	TokenOf  func(uid string) (string, error)
+	OnRevoke func(event *WbRevokeEvent) error
+}
+
+// ParseSignedRequest verify the signature of signed_request with ClientSecret and decode the payload
+// the format is base64url(hmac-sha256(payload)).base64url(payload), requests issued more than 10 minutes
+// ago or expired are rejected
+func (w *Weibo) ParseSignedRequest(signedRequest string) (*WbSignedRequest, error) {
+
+	if w.ClientSecret == "" {
+		return nil, errors.New("client secret is required to verify signed_request")
+	}
+
+	parts := strings.SplitN(signedRequest, ".", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("signed_request is invalid")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[0], "="))
+	if err != nil {
+		return nil, errors.New("signature of signed_request is not base64url encoded")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, errors.New("payload of signed_request is not base64url encoded")
+	}
+
+	if !hmac.Equal(sig, utils.HmacSha256([]byte(w.ClientSecret), []byte(parts[1]))) {
+		return nil, errors.New("signature of signed_request is invalid")
+	}
+
+	ret := new(WbSignedRequest)
+	if err := jsoniter.Unmarshal(payload, ret); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(ret.Algorithm, wbSignedRequestAlgorithm) {
+		return nil, errors.New("algorithm of signed_request is not supported")
+	}
+
+	now := time.Now()
+	issuedAt := time.Unix(ret.IssuedAt, 0)
+	if ret.IssuedAt <= 0 || now.Sub(issuedAt) > wbSignedRequestMaxAge || issuedAt.Sub(now) > wbSignedRequestClockSkew {
+		return nil, errors.New("signed_request is expired")
+	}
+	if ret.Expires > 0 && now.After(time.Unix(ret.Expires, 0)) {
+		return nil, errors.New("signed_request is expired")
+	}
+	return ret, nil
+}
+
+// ServeHTTP handle the callback, 400 for an invalid request and 500 when the confirmation or OnRevoke fails
+func (h *WbRevokeHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	h.doServeHTTP(wbTokenInfoURL, rw, r)
+}
+
+// doServeHTTP handle
+func (h *WbRevokeHandler) doServeHTTP(url string, rw http.ResponseWriter, r *http.Request) {
+
+	event, err := h.event(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.TokenOf != nil {
+		if event, err = h.confirm(url, event); err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if event != nil && h.OnRevoke != nil {
+		if err := h.OnRevoke(event); err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write([]byte("ok"))
+}
+
+// event parse the event of the request
+func (h *WbRevokeHandler) event(r *http.Request) (*WbRevokeEvent, error) {
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	if r.Form.Get("source") != h.Weibo.ClientID {
+		return nil, errors.New("source is invalid")
+	}
+	uid := r.Form.Get("uid")
+	if uid == "" {
+		return nil, errors.New("uid is required")
+	}
+
+	event := &WbRevokeEvent{UID: uid, AuthEnd: time.Now()}
+	if authEnd := r.Form.Get("auth_end"); authEnd != "" {
+		sec, err := strconv.ParseInt(authEnd, 10, 64)
+		if err != nil {
+			return nil, errors.New("auth_end is invalid")
+		}
+		event.AuthEnd = time.Unix(sec, 0)
+	}
+	return event, nil
+}
+
+// confirm check the stored token of the uid, nil when there is none or it is still valid, an error
+// when weibo does not say that the token is expired or revoked
+func (h *WbRevokeHandler) confirm(url string, event *WbRevokeEvent) (*WbRevokeEvent, error) {
+
+	token, err := h.TokenOf(event.UID)
+	if err != nil || token == "" {
+		return nil, err
+	}
+
+	info, err := h.Weibo.doTokenInfo(url, token)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case info.ErrorCode == 0 && info.ExpireIn > 0:
+		return nil, nil
+	case info.ErrorCode == 0 || wbTokenInvalid(info.ErrorCode):
+		event.Verified = true
+		return event, nil
+	default:
+		// e.g. rate limited or system error, the revocation can not be confirmed
+		return nil, fmt.Errorf("confirm the revocation error: %d %s", info.ErrorCode, info.Error)
+	}
+}
+
+// wbTokenInvalid whether the error code means the token is expired or revoked
+func wbTokenInvalid(code int) bool {
+	for _, v := range wbTokenInvalidCodes {
+		if v == code {
+			return true
+		}
+	}
+	return false
+}
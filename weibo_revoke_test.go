@@ -0,0 +1,170 @@
+package socialite
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/birjemin/socialite/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wbSign sign the payload as weibo does
+func wbSign(secret, payload string) string {
+	p := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(utils.HmacSha256([]byte(secret), []byte(p))) + "." + p
+}
+
+// TestWbParseSignedRequest
+func TestWbParseSignedRequest(t *testing.T) {
+
+	ast := assert.New(t)
+
+	now := time.Now().Unix()
+	payload := fmt.Sprintf(`{"user":{"country":"cn","locale":"zh_CN","version":5},"algorithm":"HMAC-SHA256","issued_at":%d,"expires":%d,"oauth_token":"YOUR_ACCESS_TOKEN","user_id":"1073880650","referer":"https://weibo.com"}`, now, now+86400)
+
+	// success
+	ret, err := wbObj.ParseSignedRequest(wbSign("CLIENT_SECRET", payload))
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("1073880650", ret.UserID)
+	ast.Equal("YOUR_ACCESS_TOKEN", ret.OAuthToken)
+	ast.Equal("zh_CN", ret.User.Locale)
+
+	// fail
+	_, err = wbObj.ParseSignedRequest(wbSign("OTHER_SECRET", payload))
+	ast.EqualError(err, "signature of signed_request is invalid")
+
+	_, err = wbObj.ParseSignedRequest(wbSign("CLIENT_SECRET", `{"algorithm":"HMAC-SHA1"}`))
+	ast.EqualError(err, "algorithm of signed_request is not supported")
+
+	_, err = wbObj.ParseSignedRequest("signed_request")
+	ast.Error(err)
+
+	// anyone could sign with an empty secret
+	wb := *wbObj
+	wb.ClientSecret = ""
+	_, err = wb.ParseSignedRequest(wbSign("", payload))
+	ast.EqualError(err, "client secret is required to verify signed_request")
+
+	// replayed
+	for _, v := range []string{
+		`{"algorithm":"HMAC-SHA256","user_id":"1073880650"}`,
+		fmt.Sprintf(`{"algorithm":"HMAC-SHA256","issued_at":%d,"user_id":"1073880650"}`, now-3600),
+		fmt.Sprintf(`{"algorithm":"HMAC-SHA256","issued_at":%d,"user_id":"1073880650"}`, now+3600),
+		fmt.Sprintf(`{"algorithm":"HMAC-SHA256","issued_at":%d,"expires":%d,"user_id":"1073880650"}`, now-60, now-1),
+	} {
+		_, err = wbObj.ParseSignedRequest(wbSign("CLIENT_SECRET", v))
+		ast.EqualError(err, "signed_request is expired", v)
+	}
+}
+
+// TestWbRevokeHandler
+func TestWbRevokeHandler(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"error":"invalid_access_token","error_code":21332,"request":"/oauth2/get_token_info"}`
+		switch r.FormValue("access_token") {
+		case "VALID_TOKEN":
+			ret = `{"uid":1073880650,"appkey":"CLIENT_ID","scope":"","create_at":1600000000,"expire_in":157679999}`
+		case "EXPIRED_TOKEN":
+			ret = `{"error":"expired_token","error_code":21327,"request":"/oauth2/get_token_info"}`
+		case "THROTTLED_TOKEN":
+			ret = `{"error":"User requests out of rate limit!","error_code":10023,"request":"/oauth2/get_token_info"}`
+		case "BUSY_TOKEN":
+			ret = `{"error":"system error!","error_code":10001,"request":"/oauth2/get_token_info"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	var events []*WbRevokeEvent
+	handler := &WbRevokeHandler{
+		Weibo: wbObj,
+		OnRevoke: func(event *WbRevokeEvent) error {
+			if event.UID == "FAIL" {
+				return errors.New("storage error")
+			}
+			events = append(events, event)
+			return nil
+		},
+	}
+
+	post := func(form url.Values) int {
+		r := httptest.NewRequest(http.MethodPost, "/weibo/revoke", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.doServeHTTP(ts.URL, w, r)
+		return w.Code
+	}
+
+	// not verified without TokenOf
+	ast.Equal(http.StatusOK, post(url.Values{"source": {"CLIENT_ID"}, "uid": {"1073880650"}, "auth_end": {"1600000000"}}))
+	if ast.Len(events, 1) {
+		ast.Equal("1073880650", events[0].UID)
+		ast.Equal(int64(1600000000), events[0].AuthEnd.Unix())
+		ast.False(events[0].Verified)
+	}
+
+	// confirmed with the stored token
+	tokens := map[string]string{
+		"1073880650": "VALID_TOKEN",
+		"1073880651": "REVOKED_TOKEN",
+		"1073880653": "EXPIRED_TOKEN",
+		"1073880654": "THROTTLED_TOKEN",
+		"1073880655": "BUSY_TOKEN",
+		"FAIL":       "REVOKED_TOKEN",
+	}
+	handler.TokenOf = func(uid string) (string, error) {
+		if uid == "ERROR" {
+			return "", errors.New("storage error")
+		}
+		return tokens[uid], nil
+	}
+
+	ast.Equal(http.StatusOK, post(url.Values{"source": {"CLIENT_ID"}, "uid": {"1073880651"}}))
+	if ast.Len(events, 2) {
+		ast.Equal("1073880651", events[1].UID)
+		ast.True(events[1].Verified)
+	}
+
+	ast.Equal(http.StatusOK, post(url.Values{"source": {"CLIENT_ID"}, "uid": {"1073880653"}}))
+	if ast.Len(events, 3) {
+		ast.Equal("1073880653", events[2].UID)
+		ast.True(events[2].Verified)
+	}
+
+	// forged, the token is still valid
+	ast.Equal(http.StatusOK, post(url.Values{"source": {"CLIENT_ID"}, "uid": {"1073880650"}}))
+	// nothing is stored
+	ast.Equal(http.StatusOK, post(url.Values{"source": {"CLIENT_ID"}, "uid": {"1073880652"}}))
+	ast.Len(events, 3)
+
+	// weibo is throttling or busy, the revocation is not confirmed
+	ast.Equal(http.StatusInternalServerError, post(url.Values{"source": {"CLIENT_ID"}, "uid": {"1073880654"}}))
+	ast.Equal(http.StatusInternalServerError, post(url.Values{"source": {"CLIENT_ID"}, "uid": {"1073880655"}}))
+	ast.Len(events, 3)
+
+	// fail
+	ast.Equal(http.StatusBadRequest, post(url.Values{"source": {"OTHER_CLIENT_ID"}, "uid": {"1073880650"}}))
+	ast.Equal(http.StatusBadRequest, post(url.Values{"source": {"CLIENT_ID"}}))
+	ast.Equal(http.StatusBadRequest, post(url.Values{"signed_request": {wbSign("CLIENT_SECRET", `{"algorithm":"HMAC-SHA256"}`)}}))
+	ast.Equal(http.StatusInternalServerError, post(url.Values{"source": {"CLIENT_ID"}, "uid": {"ERROR"}}))
+	ast.Equal(http.StatusInternalServerError, post(url.Values{"source": {"CLIENT_ID"}, "uid": {"FAIL"}}))
+	ast.Len(events, 3)
+}
@@ -1,6 +1,7 @@
 package socialite
 
 import (
+	"encoding/json"
 	"github.com/birjemin/socialite/utils"
 	"github.com/stretchr/testify/assert"
 	"net/http"
@@ -36,6 +37,35 @@ func TestWbGetAuthorizeURL(t *testing.T) {
 	ast.Equal(url2, wbObj.GetAuthorizeURL("STATE", "mobile"))
 }
 
+// TestWbAuthorizeURLWithOptions
+func TestWbAuthorizeURLWithOptions(t *testing.T) {
+
+	url1 := "https://api.weibo.com/oauth2/authorize?client_id=CLIENT_ID&language=en&redirect_uri=REDIRECT_URI"
+	url2 := "https://api.weibo.com/oauth2/authorize?client_id=CLIENT_ID&display=mobile&forcelogin=true&redirect_uri=REDIRECT_URI&scope=email&state=STATE"
+
+	ast := assert.New(t)
+
+	// success, empty options are omitted
+	ret, err := wbObj.AuthorizeURLWithOptions(WeiboAuthorizeOptions{Language: "en"})
+	ast.Nil(err)
+	ast.Equal(url1, ret)
+	ast.Equal(url1, wbObj.GetAuthorizeURL("", "", "", "", "en"))
+
+	ret, err = wbObj.AuthorizeURLWithOptions(WeiboAuthorizeOptions{State: "STATE", Display: WbDisplayMobile, ForceLogin: true, Scope: "email"})
+	ast.Nil(err)
+	ast.Equal(url2, ret)
+	ast.Equal(url2, wbObj.GetAuthorizeURL("STATE", "mobile", "true", "email"))
+
+	// fail
+	_, err = wbObj.AuthorizeURLWithOptions(WeiboAuthorizeOptions{Display: "desktop"})
+	ast.EqualError(err, `display "desktop" is invalid`)
+	_, err = wbObj.AuthorizeURLWithOptions(WeiboAuthorizeOptions{Language: "zh_CN"})
+	ast.Error(err)
+
+	// the positional args are passed through without validation
+	ast.Equal("https://api.weibo.com/oauth2/authorize?client_id=CLIENT_ID&display=desktop&forcelogin=1&redirect_uri=REDIRECT_URI&state=STATE", wbObj.GetAuthorizeURL("STATE", "desktop", "1"))
+}
+
 // TestWbToken
 func TestWbToken(t *testing.T) {
 
@@ -79,6 +109,26 @@ func TestWbToken(t *testing.T) {
 	ast.Equal(10021, ret.ErrorCode)
 }
 
+// TestWbTokenErrorPage
+func TestWbTokenErrorPage(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if _, err := w.Write([]byte("Service Unavailable")); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	ret, err := wbObj.doToken(ts.URL, "code")
+	ast.Nil(ret)
+	ast.True(utils.IsTemporary(err))
+	ast.EqualError(err, "http POST "+ts.URL+": status 503: Service Unavailable")
+}
+
 // TestWbUserInfo
 func TestWbUserInfo(t *testing.T) {
 
@@ -107,7 +157,8 @@ func TestWbUserInfo(t *testing.T) {
 		ast.Error(err)
 	}
 
-	ast.Equal(101, ret.ID)
+	ast.Equal(int64(101), ret.ID)
+	ast.Equal("xxx", ret.UID())
 
 	// fail
 	ret, err = wbObj.doGetUserInfo(ts.URL, "", "")
@@ -118,3 +169,268 @@ func TestWbUserInfo(t *testing.T) {
 
 	ast.Equal(10006, ret.ErrorCode)
 }
+
+// TestWbTokenInfo
+func TestWbTokenInfo(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var ret string
+		switch r.FormValue("access_token") {
+		case "YOUR_ACCESS_TOKEN":
+			ret = `{"uid":1073880650,"appkey":1352222456,"scope":null,"create_at":1352267591,"expire_in":157679471}`
+		case "EXPIRING_ACCESS_TOKEN":
+			ret = `{"uid":1073880650,"appkey":"1352222456","scope":"email","create_at":1352267591,"expire_in":3600}`
+		case "OTHER_ACCESS_TOKEN":
+			ret = `{"uid":1073880650,"appkey":1000000000,"scope":null,"create_at":1352267591,"expire_in":157679471}`
+		default:
+			ret = `{"error":"expired_token","error_code":21327,"request":"/oauth2/get_token_info"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	obj := *wbObj
+	obj.ClientID = "1352222456"
+
+	// success
+	ret, err := obj.doVerifyToken(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(int64(1073880650), ret.UID)
+	ast.Equal("", ret.Scope)
+	ast.False(ret.NeedsReauthorize(0))
+
+	ret, err = obj.doVerifyToken(ts.URL, "EXPIRING_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("email", ret.Scope)
+	ast.True(ret.NeedsReauthorize(0))
+	ast.False(ret.NeedsReauthorize(time.Minute))
+
+	// fail
+	_, err = obj.doVerifyToken(ts.URL, "OTHER_ACCESS_TOKEN")
+	ast.EqualError(err, "token is not issued for this app")
+
+	ret, err = obj.doVerifyToken(ts.URL, "")
+	ast.EqualError(err, "token is invalid")
+	ast.Equal(21327, ret.ErrorCode)
+
+	_, err = obj.RefreshToken("")
+	ast.Equal(ErrWbReauthorize, err)
+}
+
+// TestWbRevokeToken
+func TestWbRevokeToken(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"result":"true"}`
+		if r.Method != http.MethodPost || r.FormValue("access_token") == "" {
+			ret = `{"error":"invalid_access_token","error_code":21332,"request":"/oauth2/revokeoauth2"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := wbObj.doRevokeToken(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("true", ret.Result)
+
+	// fail
+	ret, err = wbObj.doRevokeToken(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(21332, ret.ErrorCode)
+}
+
+// TestWbUserInfoID
+func TestWbUserInfoID(t *testing.T) {
+
+	ast := assert.New(t)
+
+	ret := new(WbUserInfo)
+	if err := json.Unmarshal([]byte(`{"id":5678901234567,"status":{"id":4567890123456789012,"idstr":"4567890123456789012"}}`), ret); err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(int64(5678901234567), ret.ID)
+	ast.Equal("5678901234567", ret.UID())
+	ast.Equal(int64(4567890123456789012), ret.Status.ID)
+	ast.Equal("", new(WbUserInfo).UID())
+}
+
+// TestWbEmail
+func TestWbEmail(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `[{"email":"user@domain.com"}]`
+		if r.FormValue("access_token") == "" {
+			ret = `{"error":"insufficient_scopes","error_code":10032,"request":"/2/account/profile/email.json"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := wbObj.doGetEmail(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("user@domain.com", ret.Email)
+
+	// fail
+	ret, err = wbObj.doGetEmail(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(10032, ret.ErrorCode)
+}
+
+// TestWbUserInfoByScreenName
+func TestWbUserInfoByScreenName(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"id":5678901234567,"idstr":"5678901234567","screen_name":"微博用户","domain":"mydomain"}`
+		if r.FormValue("screen_name") != "微博用户" || r.FormValue("uid") != "" {
+			ret = `{"error":"User does not exists!","error_code":20003,"request":"/2/users/show.json"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := wbObj.doGetUserInfoByScreenName(ts.URL, "YOUR_ACCESS_TOKEN", "微博用户")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("5678901234567", ret.UID())
+	ast.Equal("微博用户", ret.ScreenName)
+
+	// fail
+	ret, err = wbObj.doGetUserInfoByScreenName(ts.URL, "YOUR_ACCESS_TOKEN", "unknown")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(20003, ret.ErrorCode)
+}
+
+// TestWbUserInfoByDomain
+func TestWbUserInfoByDomain(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"id":5678901234567,"idstr":"5678901234567","screen_name":"xx","domain":"mydomain"}`
+		if r.FormValue("domain") != "mydomain" {
+			ret = `{"error":"User does not exists!","error_code":20003,"request":"/2/users/domain_show.json"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := wbObj.doGetUserInfoByDomain(ts.URL, "YOUR_ACCESS_TOKEN", "mydomain")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal("5678901234567", ret.UID())
+
+	// fail
+	ret, err = wbObj.doGetUserInfoByDomain(ts.URL, "YOUR_ACCESS_TOKEN", "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(20003, ret.ErrorCode)
+}
+
+// TestWbRateLimitStatus
+func TestWbRateLimitStatus(t *testing.T) {
+
+	ast := assert.New(t)
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ret := `{"api_rate_limits":[{"api":"statuses/update","limit":30,"limit_time_unit":"HOURS","remaining_hits":29}],"ip_limit":10000,"limit_time_unit":"HOURS","remaining_ip_hits":10000,"remaining_user_hits":150,"reset_time":"2011-06-03 18:00:00","reset_time_in_seconds":2055,"user_limit":150}`
+		if r.FormValue("access_token") == "" {
+			ret = `{"error":"invalid_access_token","error_code":21332,"request":"/2/account/rate_limit_status.json"}`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(ret)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	defer ts.Close()
+
+	// success
+	ret, err := wbObj.doGetRateLimitStatus(ts.URL, "YOUR_ACCESS_TOKEN")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(29, ret.Remaining("statuses/update"))
+	ast.Equal(150, ret.Remaining("users/show"))
+	ast.Equal(2055, ret.ResetTimeInSeconds)
+
+	// fail
+	ret, err = wbObj.doGetRateLimitStatus(ts.URL, "")
+	if err != nil {
+		ast.Fail(err.Error())
+		return
+	}
+	ast.Equal(21332, ret.ErrorCode)
+}